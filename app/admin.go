@@ -0,0 +1,163 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	emtTypes "github.com/DTFN/dtfn/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txfilter"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	tmlibs "github.com/tendermint/tendermint/libs/common"
+)
+
+const adminSetKey = "AdminSet"
+
+var errAdminSetDisabled = errors.New("admin set is not configured")
+
+func (app *EthermintApplication) checkPropose(from common.Address, params json.RawMessage) error {
+	adminSet := app.strategy.AdminSet
+	if !adminSet.Enabled() {
+		return errAdminSetDisabled
+	}
+	if !adminSet.IsMember(from) {
+		return errNotAdmin
+	}
+	p := emtTypes.ProposeParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return fmt.Errorf("invalid %s params: %v", emtTypes.SystemTxPropose, err)
+	}
+	handler, ok := systemTxHandlers[p.Method]
+	if !ok {
+		return fmt.Errorf("unknown system tx method %q", p.Method)
+	}
+	if err := handler.check(app, from, p.Params); err != nil {
+		return fmt.Errorf("invalid %s params: %v", p.Method, err)
+	}
+	return nil
+}
+
+func (app *EthermintApplication) checkApprove(from common.Address, params json.RawMessage) error {
+	adminSet := app.strategy.AdminSet
+	if !adminSet.Enabled() {
+		return errAdminSetDisabled
+	}
+	if !adminSet.IsMember(from) {
+		return errNotAdmin
+	}
+	p := emtTypes.ApproveParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return fmt.Errorf("invalid %s params: %v", emtTypes.SystemTxApprove, err)
+	}
+	return adminSet.CheckApprove(p.ID, from)
+}
+
+func (app *EthermintApplication) deliverPropose(from common.Address, params json.RawMessage) ([]tmlibs.KVPair, error) {
+	p := emtTypes.ProposeParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	proposal := app.strategy.AdminSet.AddProposal(from, p.Method, p.Params, app.strategy.HFExpectedData.Height)
+	return app.tryExecuteProposal(proposal), nil
+}
+
+func (app *EthermintApplication) deliverApprove(from common.Address, params json.RawMessage) ([]tmlibs.KVPair, error) {
+	p := emtTypes.ApproveParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	proposal, err := app.strategy.AdminSet.Approve(p.ID, from)
+	if err != nil {
+		return nil, err
+	}
+	return app.tryExecuteProposal(proposal), nil
+}
+
+// tryExecuteProposal executes the proposal once it has enough approvals.
+// The params are checked again, the state may have changed since it was proposed.
+func (app *EthermintApplication) tryExecuteProposal(proposal *emtTypes.AdminProposal) []tmlibs.KVPair {
+	adminSet := app.strategy.AdminSet
+	attributes := []tmlibs.KVPair{
+		{Key: []byte("proposal"), Value: []byte(strconv.FormatUint(proposal.ID, 10))},
+		{Key: []byte("approvals"), Value: []byte(strconv.Itoa(len(proposal.Approvals)))},
+	}
+	if !adminSet.Passed(proposal) {
+		return attributes
+	}
+	adminSet.RemoveProposal(proposal.ID)
+
+	handler := systemTxHandlers[proposal.Method]
+	err := handler.check(app, proposal.Proposer, proposal.Params)
+	if err == nil {
+		err = handler.deliver(app, proposal.Proposer, proposal.Params)
+	}
+	if err != nil {
+		app.logger.Error("admin proposal execution failed", "proposal", proposal.ID, "method", proposal.Method, "err", err)
+		return append(attributes, tmlibs.KVPair{Key: []byte("error"), Value: []byte(err.Error())})
+	}
+	app.logger.Info("admin proposal executed", "proposal", proposal.ID, "method", proposal.Method,
+		"params", string(proposal.Params))
+	return append(attributes, tmlibs.KVPair{Key: []byte("executed"), Value: []byte(proposal.Method)})
+}
+
+// checkApprovedAdminTx requires the approval of the admin set for the mint and auth txs of PPChainAdmin
+func (app *EthermintApplication) checkApprovedAdminTx(from common.Address, tx *ethTypes.Transaction) error {
+	adminSet := app.strategy.AdminSet
+	if !adminSet.Enabled() || from != txfilter.PPChainAdmin || tx.To() == nil {
+		return nil
+	}
+	if !txfilter.IsAuthTx(*tx.To()) && !txfilter.IsMintTx(*tx.To()) {
+		return nil
+	}
+	if !adminSet.IsTxApproved(tx.Hash(), app.strategy.HFExpectedData.Height) {
+		return fmt.Errorf("admin tx %X is not approved by the admin set or its approval expired", tx.Hash())
+	}
+	return nil
+}
+
+func checkSetAdminSet(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.SetAdminSetParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	return emtTypes.ValidateAdminSet(p.Members, p.Threshold)
+}
+
+func deliverSetAdminSet(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.SetAdminSetParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	app.strategy.AdminSet.SetMembers(p.Members, p.Threshold)
+	return nil
+}
+
+func checkApproveTx(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.ApproveTxParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	if p.Hash == (common.Hash{}) {
+		return errors.New("empty tx hash")
+	}
+	if p.ExpiryHeight != 0 && p.ExpiryHeight <= app.strategy.HFExpectedData.Height {
+		return fmt.Errorf("expiry height %v is not after the current height %v", p.ExpiryHeight,
+			app.strategy.HFExpectedData.Height)
+	}
+	return nil
+}
+
+func deliverApproveTx(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.ApproveTxParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	expiryHeight := p.ExpiryHeight
+	if expiryHeight == 0 {
+		expiryHeight = app.strategy.HFExpectedData.Height + emtTypes.DefaultApprovedTxLifetime
+	}
+	app.strategy.AdminSet.ApproveTx(p.Hash, expiryHeight)
+	return nil
+}
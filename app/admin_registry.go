@@ -0,0 +1,85 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	emtTypes "github.com/DTFN/dtfn/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txfilter"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+	tmlibs "github.com/tendermint/tendermint/libs/common"
+)
+
+const adminRegistryKey = "AdminRegistry"
+
+// applyAdminRegistry applies the rotated accounts over the ones of the version config.
+// It runs when the registry is loaded and at the height a rotation takes effect,
+// the version switches of PPChainAdmin leave a rotated admin in place.
+func (app *EthermintApplication) applyAdminRegistry() {
+	registry := app.strategy.AdminRegistry
+	if registry.PPChainAdmin != nil {
+		txfilter.PPChainAdmin = *registry.PPChainAdmin
+	}
+	if registry.Bigguy != nil {
+		txfilter.Bigguy = *registry.Bigguy
+	}
+}
+
+// rotateAdmins applies the rotations effective at height, one event per rotation
+func (app *EthermintApplication) rotateAdmins(height int64) []abciTypes.Event {
+	applied := app.strategy.AdminRegistry.ApplyDue(height)
+	if len(applied) == 0 {
+		return nil
+	}
+	app.applyAdminRegistry()
+	events := make([]abciTypes.Event, 0, len(applied))
+	for _, rotation := range applied {
+		app.logger.Info("admin rotated", "role", rotation.Role, "account", rotation.Account.Hex(),
+			"height", height, "submittedHeight", rotation.SubmittedHeight)
+		events = append(events, abciTypes.Event{
+			Type: "adminRotation",
+			Attributes: []tmlibs.KVPair{
+				{Key: []byte("role"), Value: []byte(rotation.Role)},
+				{Key: []byte("account"), Value: []byte(strings.ToLower(rotation.Account.Hex()))},
+				{Key: []byte("submittedHeight"), Value: []byte(strconv.FormatInt(rotation.SubmittedHeight, 10))},
+			},
+		})
+	}
+	return events
+}
+
+func checkRotateAdmin(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.RotateAdminParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	if err := emtTypes.ValidateAdminRole(p.Role); err != nil {
+		return err
+	}
+	if p.Account == (common.Address{}) {
+		return fmt.Errorf("empty account")
+	}
+	// the rotation must not change the admin of the block in execution
+	if p.EffectiveHeight <= app.strategy.HFExpectedData.Height {
+		return fmt.Errorf("effective height %v is not after height %v", p.EffectiveHeight, app.strategy.HFExpectedData.Height)
+	}
+	return nil
+}
+
+func deliverRotateAdmin(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.RotateAdminParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	app.strategy.AdminRegistry.Schedule(&emtTypes.AdminRotation{
+		Role:            p.Role,
+		Account:         p.Account,
+		EffectiveHeight: p.EffectiveHeight,
+		SubmittedHeight: app.strategy.HFExpectedData.Height,
+		SubmittedBy:     from,
+	})
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/txfilter"
 	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 	abciTypes "github.com/tendermint/tendermint/abci/types"
@@ -21,6 +22,7 @@ import (
 	"github.com/tendermint/tendermint/types"
 	"math/big"
 	"strings"
+	"sync"
 )
 
 // EthermintApplication implements an ABCI application
@@ -34,6 +36,12 @@ type EthermintApplication struct {
 	getCurrentState func() (*state.StateDB, error)
 
 	checkTxState *state.StateDB
+	// checkTxMtx guards checkTxState against the pending state readers of the rpc
+	checkTxMtx sync.Mutex
+	// blockMtx is held from BeginBlock to the end of Commit and by InitChain, StopBlocks takes it for good
+	blockMtx sync.Mutex
+	// pendingState is the snapshot of checkTxState served as the pending state, nil when outdated
+	pendingState *state.StateDB
 
 	// an ethereum rpc client we can forward queries to
 	rpcClient *rpc.Client
@@ -54,6 +62,9 @@ type EthermintApplication struct {
 
 	punishment *Punishment
 
+	// minGasPrice is the per node mempool price floor
+	minGasPrice *big.Int
+
 	logger tmLog.Logger
 }
 
@@ -82,6 +93,8 @@ func NewEthermintApplication(backend *ethereum.Backend,
 	if err := app.backend.InitEthState(common.HexToAddress(app.backend.InitReceiver())); err != nil {
 		return nil, err
 	}
+	backend.Es().SetPendingState(app.PendingState)
+	backend.SetStrategy(strategy)
 
 	return app, nil
 }
@@ -157,6 +170,8 @@ func (app *EthermintApplication) SetOption(req abciTypes.RequestSetOption) abciT
 // #stable - 0.4.0
 func (app *EthermintApplication) InitChain(req abciTypes.RequestInitChain) abciTypes.ResponseInitChain {
 	app.logger.Info("InitChain", "len(req.Validators)", len(req.Validators)) // nolint: errcheck
+	app.blockMtx.Lock()
+	defer app.blockMtx.Unlock()
 	ethState, _ := app.getCurrentState()
 	initialValidators := []abciTypes.ValidatorUpdate{}
 	app.SetPosTableThreshold()
@@ -168,8 +183,8 @@ func (app *EthermintApplication) InitChain(req abciTypes.RequestInitChain) abciT
 		tmPubKey, _ := types.PB2TM.PubKey(pubKey)
 		address := tmPubKey.Address().String()
 		if app.strategy.AccMapInitial.MapList[address] == nil {
-			app.logger.Error(fmt.Sprintf("initChain address %v not found in initialAccountMap, ignore. Please check configuration!", address))
-			continue
+			// the account map is checked against the genesis at startup, a miss means another genesis
+			panic(fmt.Sprintf("InitChain, validator %v not found in initialAccountMap. check initial_eth_account.json", address))
 		}
 		signer := app.strategy.AccMapInitial.MapList[address].Signer
 		signerBalance := ethState.GetBalance(signer)
@@ -200,17 +215,19 @@ func (app *EthermintApplication) InitChain(req abciTypes.RequestInitChain) abciT
 		"validators", app.strategy.InitialValidators)
 	if initialValidatorsLen != 0 {
 		app.strategy.NextEpochValData.PosTable.InitStruct()
-		app.strategy.CurrEpochValData.PosTable = app.strategy.NextEpochValData.PosTable.Copy()
-		txfilter.CurrentPosTable = app.strategy.CurrEpochValData.PosTable
+		currPosTable := app.strategy.NextEpochValData.PosTable.Copy()
+		currPosTable.ExportSortedSigners()
+		app.strategy.SetCurrEpochPosTable(currPosTable)
+		txfilter.CurrentPosTable = currPosTable
 		txfilter.EthAuthTableCopy = txfilter.EthAuthTable.Copy()
-		app.strategy.CurrEpochValData.PosTable.ExportSortedSigners()
 	} else {
 		panic("no qualified initial validators, please check config")
 	}
 
+	app.initBlockGasLimit(req)
 	app.SetPersistenceData()
 
-	return abciTypes.ResponseInitChain{}
+	return abciTypes.ResponseInitChain{ConsensusParams: app.consensusParamUpdates()}
 }
 
 // CheckTx checks a transaction is valid but does not mutate the state
@@ -218,7 +235,15 @@ func (app *EthermintApplication) InitChain(req abciTypes.RequestInitChain) abciT
 func (app *EthermintApplication) CheckTx(req abciTypes.RequestCheckTx) abciTypes.ResponseCheckTx {
 	var tx *ethTypes.Transaction
 	if req.Type == abciTypes.CheckTxType_Local {
-		tx = app.backend.CurrentTxInfo().Tx
+		// the hash of an eth tx is the hash of its rlp encoding, no need to decode it
+		txInfo, ok := app.backend.LocalTxInfo(crypto.Keccak256Hash(req.Tx))
+		if !ok {
+			return abciTypes.ResponseCheckTx{
+				Code: uint32(emtTypes.CodeInternal),
+				Log:  "local tx was not sent by the broadcaster",
+			}
+		}
+		tx = txInfo.Tx
 	} else {
 		txBytes := req.Tx
 		var err error
@@ -235,12 +260,59 @@ func (app *EthermintApplication) CheckTx(req abciTypes.RequestCheckTx) abciTypes
 
 	app.logger.Debug("CheckTx: Received valid transaction", "tx", tx) // nolint: errcheck
 
-	return app.validateTx(tx, req.Type)
+	app.checkTxMtx.Lock()
+	defer app.checkTxMtx.Unlock()
+	res := app.validateTx(tx, req.Type)
+	if res.IsOK() {
+		app.pendingState = nil
+	}
+	app.trackCheckTx(tx.Hash(), req.Type, res)
+	return res
+}
+
+// trackCheckTx records the outcome of CheckTx in the tx tracker, a failed recheck evicts the tx from the mempool
+func (app *EthermintApplication) trackCheckTx(txHash common.Hash, checkType abciTypes.CheckTxType, res abciTypes.ResponseCheckTx) {
+	tracker := app.backend.TxTracker()
+	reason := fmt.Sprintf("code %v: %v", res.Code, res.Log)
+	switch {
+	case checkType == abciTypes.CheckTxType_Recheck:
+		if !res.IsOK() {
+			tracker.Record(txHash, ethereum.TxStageEvicted, reason, 0)
+		}
+	case res.IsOK():
+		tracker.Record(txHash, ethereum.TxStageMempool, "", 0)
+	default:
+		tracker.Record(txHash, ethereum.TxStageCheckTxFailed, reason, 0)
+	}
+}
+
+// PendingState returns a copy of the check tx state, the latest state plus the txs in the mempool.
+// The snapshot is taken again after a Commit or a CheckTx that changed the state.
+func (app *EthermintApplication) PendingState() *state.StateDB {
+	app.checkTxMtx.Lock()
+	defer app.checkTxMtx.Unlock()
+
+	if app.pendingState == nil {
+		app.pendingState = app.checkTxState.Copy()
+	}
+	return app.pendingState.Copy()
 }
 
 // DeliverTx executes a transaction against the latest state
 // #stable - 0.4.0
 func (app *EthermintApplication) DeliverTx(req abciTypes.RequestDeliverTx) abciTypes.ResponseDeliverTx {
+	res := app.deliverTx(req)
+	height := app.strategy.HFExpectedData.Height
+	if res.IsOK() {
+		app.backend.TxTracker().Record(crypto.Keccak256Hash(req.Tx), ethereum.TxStageDelivered, "", height)
+	} else {
+		app.backend.TxTracker().Record(crypto.Keccak256Hash(req.Tx), ethereum.TxStageDeliverTxFailed,
+			fmt.Sprintf("code %v: %v", res.Code, res.Log), height)
+	}
+	return res
+}
+
+func (app *EthermintApplication) deliverTx(req abciTypes.RequestDeliverTx) abciTypes.ResponseDeliverTx {
 	tx, err := decodeTx(req.Tx)
 	if err != nil {
 		// nolint: errcheck
@@ -251,6 +323,12 @@ func (app *EthermintApplication) DeliverTx(req abciTypes.RequestDeliverTx) abciT
 		}
 	}
 
+	if err := app.checkConsensusGasPrice(tx, app.strategy.HFExpectedData.Height); err != nil {
+		return abciTypes.ResponseDeliverTx{
+			Code: uint32(emtTypes.CodeInsufficientFee),
+			Log:  err.Error()}
+	}
+
 	txHash := tx.Hash()
 	txInfo, ok := app.backend.FetchCachedTxInfo(txHash)
 	if !ok {
@@ -344,30 +422,60 @@ func (app *EthermintApplication) DeliverTx(req abciTypes.RequestDeliverTx) abciT
 		app.backend.DeleteCachedTxInfo(txHash)
 	}
 
+	if err := app.strategy.TxPolicy.Check(txInfo.From, tx.To(), tx.Value()); err != nil {
+		return abciTypes.ResponseDeliverTx{
+			Code: uint32(emtTypes.CodeUnauthorized),
+			Log:  fmt.Sprintf("Tx is not allowed: %v", err)}
+	}
+	if err := app.checkApprovedAdminTx(txInfo.From, tx); err != nil {
+		return abciTypes.ResponseDeliverTx{
+			Code: uint32(emtTypes.CodeUnauthorized),
+			Log:  err.Error()}
+	}
+
+	isSystemTx := emtTypes.IsSystemTx(tx.To())
+	if isSystemTx {
+		if err := app.checkSystemTx(txInfo.From, tx); err != nil {
+			return abciTypes.ResponseDeliverTx{
+				Code: uint32(emtTypes.CodeUnauthorized),
+				Log:  err.Error()}
+		}
+	}
+
 	res := app.backend.DeliverTx(tx, app.strategy.HFExpectedData.BlockVersion, txInfo)
 	if res.IsErr() {
 		// nolint: errcheck
 		app.logger.Error("DeliverTx: Error delivering tx to ethereum backend", "tx", tx,
-			"err", err)
+			"err", res.Log)
 		return res
 	}
-	//app.CollectTx(tx)
-	return abciTypes.ResponseDeliverTx{
-		Code: abciTypes.CodeTypeOK,
+	app.strategy.AdminSet.ConsumeApprovedTx(txHash)
+	if isSystemTx {
+		events, err := app.deliverSystemTx(txInfo.From, tx)
+		if err != nil {
+			app.logger.Error("DeliverTx: Error applying system tx", "tx", tx, "err", err)
+		} else {
+			res.Events = append(res.Events, events...)
+		}
 	}
+	app.backend.IndexTxHash(types.Tx(req.Tx).Hash(), txHash)
+	//app.CollectTx(tx)
+	return res
 }
 
 // BeginBlock starts a new Ethereum block
 // #stable - 0.4.0
 func (app *EthermintApplication) BeginBlock(beginBlock abciTypes.RequestBeginBlock) abciTypes.ResponseBeginBlock {
 	app.logger.Debug("BeginBlock") // nolint: errcheck
+	// released at the end of Commit
+	app.blockMtx.Lock()
 	app.strategy.NextEpochValData.PosTable.ChangedFlagThisBlock = false
 	header := beginBlock.GetHeader()
 	// update the eth header with the tendermint header!breaking!!
 	app.backend.UpdateHeaderWithTimeInfo(&header)
 	app.strategy.HFExpectedData.Height = beginBlock.GetHeader().Height
 	app.strategy.HFExpectedData.BlockVersion = beginBlock.GetHeader().Version.App
-	app.strategy.CurrentHeightValData.Height = beginBlock.GetHeader().Height
+	app.strategy.SetCurrentHeight(beginBlock.GetHeader().Height)
 	//when we reach the upgrade height,we change the blockversion
 
 	if app.strategy.HFExpectedData.IsHarfForkPassed {
@@ -380,6 +488,7 @@ func (app *EthermintApplication) BeginBlock(beginBlock abciTypes.RequestBeginBlo
 	}
 	app.logger.Info("block version", "appVersion", app.strategy.HFExpectedData.BlockVersion)
 	txfilter.AppVersion = app.strategy.HFExpectedData.BlockVersion
+	events := app.rotateAdmins(app.strategy.HFExpectedData.Height)
 	//if app.strategy.HFExpectedData.IsHarfForkPassed && app.strategy.HFExpectedData.Height == version.NextHardForkHeight {
 	//	app.strategy.HFExpectedData.BlockVersion = version.NextHardForkVersion
 	//}
@@ -392,11 +501,13 @@ func (app *EthermintApplication) BeginBlock(beginBlock abciTypes.RequestBeginBlo
 	db, e := app.getCurrentState()
 	if e == nil {
 		//app.logger.Info("do punish")
-		app.punishment.DoPunish(db, app.strategy, beginBlock.ByzantineValidators, coinbase, beginBlock.Header.Height)
+		slashes := app.punishment.DoPunish(db, app.strategy, beginBlock.ByzantineValidators, coinbase, beginBlock.Header.Height)
+		app.backend.RecordSlashes(slashes)
+		events = append(events, slashEvents(slashes)...)
 	}
 	storedcfg := app.backend.Ethereum().BlockChain().Config()
 	fmt.Printf("-------currentheight chainconfig %v rules %v \n", storedcfg, storedcfg.Rules(big.NewInt(beginBlock.Header.Height)))
-	return abciTypes.ResponseBeginBlock{}
+	return abciTypes.ResponseBeginBlock{Events: events}
 }
 
 // EndBlock accumulates rewards for the validators and updates them
@@ -407,12 +518,14 @@ func (app *EthermintApplication) EndBlock(endBlock abciTypes.RequestEndBlock) ab
 	height := endBlock.Height
 	if height%txfilter.EpochBlocks == 0 {
 		//DeepCopy
-		app.strategy.CurrEpochValData.PosTable = app.strategy.NextEpochValData.PosTable.Copy()
-		app.strategy.CurrEpochValData.PosTable.ExportSortedSigners()
-		txfilter.CurrentPosTable = app.strategy.CurrEpochValData.PosTable
+		currPosTable := app.strategy.NextEpochValData.PosTable.Copy()
+		currPosTable.ExportSortedSigners()
+		app.strategy.SetCurrEpochPosTable(currPosTable)
+		txfilter.CurrentPosTable = currPosTable
 		txfilter.EthAuthTableCopy = txfilter.EthAuthTable.Copy()
 		count := app.strategy.NextEpochValData.PosTable.TryRemoveUnbondPosItems(app.strategy.CurrentHeightValData.Height, app.strategy.CurrEpochValData.PosTable.SortedUnbondSigners)
 		app.GetLogger().Info(fmt.Sprintf("total remove %d Validators.", count))
+		app.strategy.ConsensusKeyRotations.SwitchEpoch(app.strategy.NextEpochValData.PosTable, height)
 
 		if height == version.HeightArray[3] { //force update genesis config to Constantinople
 			db := app.backend.Ethereum().ChainDb()
@@ -431,12 +544,24 @@ func (app *EthermintApplication) EndBlock(endBlock abciTypes.RequestEndBlock) ab
 			}
 		}
 	}
-	return app.GetUpdatedValidators(endBlock.GetHeight(), endBlock.GetSeed())
+	app.activateBlockGasLimit(height)
+	if count := app.strategy.AdminSet.PruneApprovedTxs(height); count > 0 {
+		app.logger.Info("expired admin tx approvals removed", "count", count)
+	}
+	res := app.GetUpdatedValidators(endBlock.GetHeight(), endBlock.GetSeed())
+	if app.strategy.ConsensusParams.ChangedFlagThisBlock {
+		// both tendermint and the eth header use the new limit from the next height
+		app.applyBlockGasLimit()
+		res.ConsensusParamUpdates = app.consensusParamUpdates()
+		app.logger.Info("block gas limit updated", "gasLimit", app.strategy.ConsensusParams.BlockGasLimit)
+	}
+	return res
 }
 
 // Commit commits the block and returns a hash of the current state
 // #stable - 0.4.0
 func (app *EthermintApplication) Commit() abciTypes.ResponseCommit {
+	defer app.blockMtx.Unlock()
 
 	app.backend.AccumulateRewards(app.strategy)
 	app.SetPersistenceData()
@@ -451,7 +576,10 @@ func (app *EthermintApplication) Commit() abciTypes.ResponseCommit {
 		app.strategy.NextEpochValData))
 	state.Finalise(true)
 	app.logger.Debug(fmt.Sprintf("After finalise Commit trie.root=%X",state.Trie().Hash()))*/
+	app.checkTxMtx.Lock()
 	app.checkTxState = state.Copy() //commit里会做recheck，需要先重置checkState,通过recheck也正好将checkState恢复到正确的状态
+	app.pendingState = nil
+	app.checkTxMtx.Unlock()
 	blockHash, err := app.backend.Commit()
 	if err != nil {
 		// nolint: errcheck
@@ -485,15 +613,46 @@ func (app *EthermintApplication) Query(query abciTypes.RequestQuery) abciTypes.R
 			result = app.strategy.CurrEpochValData.PosTable.PosItemMap
 		} else if query.Path == "PosTable/GetNextPosTable" {
 			result = app.strategy.NextEpochValData.PosTable.PosItemMap
+		} else if query.Path == "PosTable/GetConsensusKeyRotations" {
+			result = app.strategy.ConsensusKeyRotations.Copy()
 		} else { //default
 			result = app.strategy.NextEpochValData.PosTable.PosItemMap
 		}
 	} else if index := strings.Index(query.Path, "AuthTable"); index >= 0 {
 		if query.Path == "AuthTable/GetAuthTable" {
 			result = txfilter.EthAuthTable.AuthItemMap
+		} else if query.Path == "AuthTable/GetTxPolicy" {
+			result = app.strategy.TxPolicy.Copy()
 		} else { //default
 			result = txfilter.EthAuthTable.AuthItemMap
 		}
+	} else if index := strings.Index(query.Path, "AdminSet"); index >= 0 {
+		if query.Path == "AdminSet/GetProposals" {
+			result = app.strategy.AdminSet.Copy().Proposals
+		} else { //default
+			result = app.strategy.AdminSet.Copy()
+		}
+	} else if index := strings.Index(query.Path, "Slashes"); index >= 0 {
+		// params: [fromHeight, toHeight], both optional
+		fromHeight, toHeight := int64(0), int64(0)
+		if len(in.Params) > 0 {
+			if height, ok := in.Params[0].(float64); ok {
+				fromHeight = int64(height)
+			}
+		}
+		if len(in.Params) > 1 {
+			if height, ok := in.Params[1].(float64); ok {
+				toHeight = int64(height)
+			}
+		}
+		slashes, err := app.backend.SlashRecords(fromHeight, toHeight)
+		if err != nil {
+			return abciTypes.ResponseQuery{Code: uint32(emtTypes.CodeInternal),
+				Log: err.Error()}
+		}
+		result = slashes
+	} else if index := strings.Index(query.Path, "AdminRegistry"); index >= 0 {
+		result = app.strategy.AdminRegistry.Copy()
 	} else if index := strings.Index(query.Path, "/p2p/whitelist"); index >= 0 {
 		authTableMap := make(map[string]int64)
 		for key, ai := range txfilter.EthAuthTable.AuthItemMap {
@@ -509,6 +668,10 @@ func (app *EthermintApplication) Query(query abciTypes.RequestQuery) abciTypes.R
 		for _, pi := range app.strategy.NextEpochValData.PosTable.PosItemMap {
 			authTableMap[pi.TmAddress] = pi.Height
 		}
+		// the old key of a pending rotation keeps validating until the epoch boundary
+		for _, rotation := range app.strategy.ConsensusKeyRotations.Copy().Pending {
+			authTableMap[rotation.OldTmAddress] = rotation.SubmittedHeight
+		}
 		result = authTableMap
 	}  else {
 		if err := app.rpcClient.Call(&result, in.Method, in.Params...); err != nil {
@@ -549,7 +712,7 @@ func (app *EthermintApplication) validateTx(tx *ethTypes.Transaction, checkType
 	isRelayTx := false
 	txHash := tx.Hash()
 	if checkType == abciTypes.CheckTxType_Local {
-		txInfo = app.backend.CurrentTxInfo()
+		txInfo, _ = app.backend.LocalTxInfo(txHash)
 		from = txInfo.From
 		if txInfo.SubTx != nil {
 			relayer = txInfo.RelayFrom
@@ -677,6 +840,18 @@ func (app *EthermintApplication) validateTx(tx *ethTypes.Transaction, checkType
 			Log:  core.ErrNegativeValue.Error()}
 	}
 
+	// The tx must pay at least the node and consensus minimum gas price.
+	if err := app.checkLocalGasPrice(tx); err != nil {
+		return abciTypes.ResponseCheckTx{
+			Code: uint32(emtTypes.CodeInsufficientFee),
+			Log:  err.Error()}
+	}
+	if err := app.checkConsensusGasPrice(tx, app.strategy.HFExpectedData.Height+1); err != nil {
+		return abciTypes.ResponseCheckTx{
+			Code: uint32(emtTypes.CodeInsufficientFee),
+			Log:  err.Error()}
+	}
+
 	currentState := app.checkTxState
 
 	// Make sure the account exist - cant send from non-existing account.
@@ -726,11 +901,17 @@ func (app *EthermintApplication) validateTx(tx *ethTypes.Transaction, checkType
 
 	intrGas, err := core.IntrinsicGas(tx.Data(), tx.To() == nil, true,false) // homestead == true
 
-	if err != nil && tx.Gas() < intrGas {
+	if err != nil {
 		return abciTypes.ResponseCheckTx{
 			Code: uint32(emtTypes.CodeInsufficientCoins),
 			Log:  err.Error()}
 	}
+	// GasWanted is reported as tx.Gas(), it has to cover the intrinsic gas
+	if tx.Gas() < intrGas {
+		return abciTypes.ResponseCheckTx{
+			Code: uint32(emtTypes.CodeOutOfGas),
+			Log:  core.ErrIntrinsicGas.Error()}
+	}
 	height := app.backend.Es().WorkState().Height()
 	err = txfilter.IsBetBlocked(from, tx.To(), currentBalance, tx.Data(), height, false)
 	if err != nil {
@@ -742,6 +923,26 @@ func (app *EthermintApplication) validateTx(tx *ethTypes.Transaction, checkType
 				err)}
 	}
 
+	if err := app.strategy.TxPolicy.Check(from, tx.To(), tx.Value()); err != nil {
+		return abciTypes.ResponseCheckTx{
+			Code: uint32(emtTypes.CodeUnauthorized),
+			Log:  fmt.Sprintf("Tx is not allowed: %v", err)}
+	}
+
+	if err := app.checkApprovedAdminTx(from, tx); err != nil {
+		return abciTypes.ResponseCheckTx{
+			Code: uint32(emtTypes.CodeUnauthorized),
+			Log:  err.Error()}
+	}
+
+	if emtTypes.IsSystemTx(tx.To()) {
+		if err := app.checkSystemTx(from, tx); err != nil {
+			return abciTypes.ResponseCheckTx{
+				Code: uint32(emtTypes.CodeUnauthorized),
+				Log:  fmt.Sprintf("System tx failed, %v", err)}
+		}
+	}
+
 	if tx.To() != nil {
 		if txfilter.IsAuthTx(*tx.To()) {
 			err := txfilter.IsAuthBlocked(from, tx.Data(), height, false)
@@ -783,7 +984,8 @@ func (app *EthermintApplication) validateTx(tx *ethTypes.Transaction, checkType
 		app.backend.InsertCachedTxInfo(txHash, txInfo)
 	}
 	success = true
-	return abciTypes.ResponseCheckTx{Code: abciTypes.CodeTypeOK, GasWanted: int64(intrGas)}
+	return abciTypes.ResponseCheckTx{Code: abciTypes.CodeTypeOK, GasWanted: int64(tx.Gas()),
+		Events: app.priorityEvents(tx)}
 }
 
 func (app *EthermintApplication) GetStrategy() *emtTypes.Strategy {
@@ -0,0 +1,668 @@
+// Package apptest runs several EthermintApplication instances in one process.
+// A Network plays the part of Tendermint: it proposes blocks with a rotating proposer,
+// reports the votes and the evidences of the previous height, applies the validator
+// updates two heights later and checks that every node ends each block with the same app hash.
+//
+// The apps share the package level state of txfilter and version, the nodes are run one after
+// the other for each block so each of them sees the values it set itself.
+package apptest
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txfilter"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+	tmCrypto "github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	tmLog "github.com/tendermint/tendermint/libs/log"
+	tmTypes "github.com/tendermint/tendermint/types"
+
+	"github.com/DTFN/dtfn/app"
+	"github.com/DTFN/dtfn/ethereum"
+	emtTypes "github.com/DTFN/dtfn/types"
+	"github.com/DTFN/dtfn/version"
+)
+
+// Config describes the network built by NewNetwork
+type Config struct {
+	// Nodes is the number of apps, all of them execute every block
+	Nodes int
+	// Validators is the number of genesis validators, Candidates the number of funded keys left out of the genesis
+	Validators int
+	Candidates int
+	// Balance of every validator and candidate signer
+	Balance *big.Int
+	// Accounts funded in the genesis on top of the signers
+	Accounts map[common.Address]*big.Int
+	// ChainID of both the eth chain and tendermint
+	ChainID int64
+	// HeightString and VersionString are the fork heights of the version config, see version.yaml
+	HeightString  string
+	VersionString string
+	// SelectCount is the number of validators selected at each height, 0 selects all of them
+	SelectCount int
+	Logger      tmLog.Logger
+}
+
+// DefaultConfig returns a network of 4 nodes and 4 validators using the develop fork heights
+func DefaultConfig() Config {
+	return Config{
+		Nodes:         4,
+		Validators:    4,
+		Balance:       new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil),
+		ChainID:       15,
+		HeightString:  "20,30,40,200",
+		VersionString: "2,3,4,5",
+		Logger:        tmLog.NewNopLogger(),
+	}
+}
+
+// Validator holds the keys of a genesis validator or of a candidate
+type Validator struct {
+	Name        string
+	TmKey       tmCrypto.PrivKey
+	EthKey      *ecdsa.PrivateKey
+	Beneficiary common.Address
+}
+
+// Signer is the eth account bonded with the tendermint key
+func (val *Validator) Signer() common.Address {
+	return crypto.PubkeyToAddress(val.EthKey.PublicKey)
+}
+
+func (val *Validator) TmAddress() string {
+	return val.TmKey.PubKey().Address().String()
+}
+
+func (val *Validator) PubKey() abciTypes.PubKey {
+	return tmTypes.TM2PB.PubKey(val.TmKey.PubKey())
+}
+
+// BlsKeyString is the bls key of the account map, the harness does not aggregate signatures
+func (val *Validator) BlsKeyString() string {
+	blsKeyJSON, _ := json.Marshal(tmTypes.BLSPubKey{Type: "Secp256k1", Address: val.TmAddress()})
+	return string(blsKeyJSON)
+}
+
+func newValidator(name string) *Validator {
+	ethKey, err := crypto.ToECDSA(crypto.Keccak256([]byte("apptest eth " + name)))
+	if err != nil {
+		panic(err)
+	}
+	return &Validator{
+		Name:        name,
+		TmKey:       ed25519.GenPrivKeyFromSecret([]byte("apptest tm " + name)),
+		EthKey:      ethKey,
+		Beneficiary: common.BytesToAddress(crypto.Keccak256([]byte("apptest beneficiary " + name))),
+	}
+}
+
+// Node is one app with its own in-memory chain
+type Node struct {
+	App     *app.EthermintApplication
+	Backend *ethereum.Backend
+	stack   *ethereum.Node
+
+	rpcServer *rpc.Server
+	rpc       *rpc.Client
+}
+
+// Block is what the first node returned for a height, the others returned the same
+type Block struct {
+	Height     int64
+	Proposer   string
+	BeginBlock abciTypes.ResponseBeginBlock
+	DeliverTxs []abciTypes.ResponseDeliverTx
+	EndBlock   abciTypes.ResponseEndBlock
+	AppHash    []byte
+}
+
+// Events returns the events of the given type emitted by BeginBlock and DeliverTx
+func (block *Block) Events(eventType string) []abciTypes.Event {
+	var events []abciTypes.Event
+	for _, event := range block.BeginBlock.Events {
+		if event.Type == eventType {
+			events = append(events, event)
+		}
+	}
+	for _, res := range block.DeliverTxs {
+		for _, event := range res.Events {
+			if event.Type == eventType {
+				events = append(events, event)
+			}
+		}
+	}
+	return events
+}
+
+// Network drives the nodes through the ABCI calls of each height
+type Network struct {
+	t testing.TB
+
+	Nodes      []*Node
+	Validators []*Validator
+	Candidates []*Validator
+
+	chainID    int64
+	signer     ethTypes.Signer
+	height     int64
+	time       time.Time
+	appVersion uint64
+
+	// validator sets by height, the updates of EndBlock(h) are used from h+2
+	valSets   map[int64][]abciTypes.ValidatorUpdate
+	absent    map[string]bool
+	evidences []abciTypes.Evidence
+	nonces    map[common.Address]uint64
+	hooks     map[int64][]func(*Network)
+	// commitHooks run right before node i commits the block at height
+	commitHooks map[int64][]func(net *Network, i int)
+}
+
+// NewNetwork builds the genesis, starts the nodes and runs InitChain on each of them
+func NewNetwork(t testing.TB, cfg Config) *Network {
+	version.HeightString = cfg.HeightString
+	version.VersionString = cfg.VersionString
+	if err := version.InitConfig(); err != nil {
+		t.Fatalf("version config: %v", err)
+	}
+
+	net := &Network{
+		t:           t,
+		chainID:     cfg.ChainID,
+		signer:      ethTypes.NewEIP155Signer(big.NewInt(cfg.ChainID)),
+		time:        time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		valSets:     make(map[int64][]abciTypes.ValidatorUpdate),
+		absent:      make(map[string]bool),
+		nonces:      make(map[common.Address]uint64),
+		hooks:       make(map[int64][]func(*Network)),
+		commitHooks: make(map[int64][]func(*Network, int)),
+	}
+	alloc := core.GenesisAlloc{}
+	for address, balance := range cfg.Accounts {
+		alloc[address] = core.GenesisAccount{Balance: balance}
+	}
+	for i := 0; i < cfg.Validators+cfg.Candidates; i++ {
+		val := newValidator(fmt.Sprintf("val%d", i))
+		alloc[val.Signer()] = core.GenesisAccount{Balance: cfg.Balance}
+		if i < cfg.Validators {
+			net.Validators = append(net.Validators, val)
+		} else {
+			net.Candidates = append(net.Candidates, val)
+		}
+	}
+	genesis := &core.Genesis{
+		Config: &params.ChainConfig{
+			ChainID:        big.NewInt(cfg.ChainID),
+			HomesteadBlock: big.NewInt(0),
+			EIP155Block:    big.NewInt(0),
+			EIP158Block:    big.NewInt(0),
+		},
+		Difficulty: big.NewInt(0x40),
+		GasLimit:   params.GenesisGasLimit,
+		Alloc:      alloc,
+	}
+
+	ethAccounts := &emtTypes.EthAccounts{
+		Version:  emtTypes.InitialEthAccountVersion,
+		Accounts: make(map[string]*emtTypes.InitialEthAccount),
+	}
+	genVals := make([]tmTypes.GenesisValidator, 0, len(net.Validators))
+	initialValidators := make([]abciTypes.ValidatorUpdate, 0, len(net.Validators))
+	for _, val := range net.Validators {
+		ethAccounts.Accounts[val.TmAddress()] = &emtTypes.InitialEthAccount{
+			Signer:      val.Signer(),
+			Beneficiary: val.Beneficiary,
+		}
+		genVals = append(genVals, tmTypes.GenesisValidator{
+			PubKey: val.TmKey.PubKey(),
+			BlsPubKey: tmTypes.BLSPubKey{
+				Type:    "Secp256k1",
+				Address: val.TmAddress(),
+			},
+			Power: 1,
+			Name:  val.Name,
+		})
+		initialValidators = append(initialValidators, abciTypes.ValidatorUpdate{PubKey: val.PubKey(), Power: 1})
+	}
+	net.valSets[1] = initialValidators
+	net.valSets[2] = initialValidators
+
+	for i := 0; i < cfg.Nodes; i++ {
+		net.Nodes = append(net.Nodes, net.newNode(cfg, genesis, ethAccounts, genVals))
+	}
+	for i, n := range net.Nodes {
+		n.App.InitChain(abciTypes.RequestInitChain{
+			Time:       net.time,
+			ChainId:    fmt.Sprintf("apptest-%d", cfg.ChainID),
+			Validators: initialValidators,
+		})
+		if i == 0 {
+			continue
+		}
+		// InitChain commits nothing, compare the genesis state instead
+		if !bytes.Equal(net.stateRoot(n), net.stateRoot(net.Nodes[0])) {
+			t.Fatalf("node %d genesis state %X differs from node 0 %X", i, net.stateRoot(n), net.stateRoot(net.Nodes[0]))
+		}
+	}
+	return net
+}
+
+// newNode mirrors the startup of ethermintCmd on an in-memory datadir
+func (net *Network) newNode(cfg Config, genesis *core.Genesis, ethAccounts *emtTypes.EthAccounts,
+	genVals []tmTypes.GenesisValidator) *Node {
+	nodeConfig := node.DefaultConfig
+	nodeConfig.DataDir = ""
+	nodeConfig.IPCPath = ""
+	nodeConfig.HTTPHost = ""
+	nodeConfig.WSHost = ""
+	nodeConfig.P2P.MaxPeers = 0
+	nodeConfig.P2P.NoDiscovery = true
+	nodeConfig.P2P.ListenAddr = ""
+	stack, err := ethereum.New(&nodeConfig)
+	if err != nil {
+		net.t.Fatalf("new node: %v", err)
+	}
+	ethConfig := eth.DefaultConfig
+	ethConfig.Genesis = genesis
+	ethConfig.NetworkId = uint64(cfg.ChainID)
+	ethConfig.Ethash.PowMode = ethash.ModeNil
+	ethConfig.Miner.GasCeil = 0
+	backend, err := ethereum.NewBackend(stack, &ethConfig, nil)
+	if err != nil {
+		net.t.Fatalf("new backend: %v", err)
+	}
+
+	ethApp, err := app.NewEthermintApplication(backend, nil, emtTypes.NewStrategy())
+	if err != nil {
+		net.t.Fatalf("new app: %v", err)
+	}
+	ethApp.SetLogger(cfg.Logger)
+	strategy := ethApp.GetStrategy()
+	strategy.SetSigner(big.NewInt(cfg.ChainID))
+	strategy.CurrEpochValData.SelectCount = cfg.SelectCount
+	strategy.CurrEpochValData.DKGMembersLimit = 100
+	if ethApp.InitPersistData() {
+		net.t.Fatalf("in-memory node has persisted data")
+	}
+
+	totalBalance := big.NewInt(0)
+	for _, account := range genesis.Alloc {
+		totalBalance.Add(totalBalance, account.Balance)
+	}
+	strategy.CurrEpochValData.TotalBalance = totalBalance
+	threshold := new(big.Int).Div(totalBalance, big.NewInt(txfilter.ThresholdUnit))
+	amlist, err := ethAccounts.InitialAccountMap(genVals, func(signer common.Address) *big.Int {
+		if account, ok := genesis.Alloc[signer]; ok {
+			return account.Balance
+		}
+		return nil
+	}, threshold)
+	if err != nil {
+		net.t.Fatalf("initial account map: %v", err)
+	}
+	strategy.SetInitialAccountMap(amlist)
+	return &Node{App: ethApp, Backend: backend, stack: stack}
+}
+
+// RPC returns an in-process client serving the apis of the backend, it is closed with the network
+func (n *Node) RPC() *rpc.Client {
+	if n.rpc == nil {
+		server := rpc.NewServer()
+		for _, api := range n.Backend.APIs() {
+			if err := server.RegisterName(api.Namespace, api.Service); err != nil {
+				panic(fmt.Sprintf("register api %v: %v", api.Namespace, err))
+			}
+		}
+		n.rpcServer = server
+		n.rpc = rpc.DialInProc(server)
+	}
+	return n.rpc
+}
+
+// Restart replaces the app of node i by a new one loading its data from the committed state,
+// as ethermintCmd does when the node is started again. The chain db is kept.
+func (net *Network) Restart(i int) {
+	n := net.Nodes[i]
+	ethApp, err := app.NewEthermintApplication(n.Backend, nil, emtTypes.NewStrategy())
+	if err != nil {
+		net.t.Fatalf("restart node %d: %v", i, err)
+	}
+	ethApp.SetLogger(n.App.GetLogger())
+	ethApp.GetStrategy().SetSigner(big.NewInt(net.chainID))
+	if !ethApp.InitPersistData() {
+		net.t.Fatalf("node %d restarted without persisted data", i)
+	}
+	n.App = ethApp
+}
+
+func (net *Network) stateRoot(n *Node) []byte {
+	state, err := n.Backend.Es().State()
+	if err != nil {
+		net.t.Fatalf("work state: %v", err)
+	}
+	return state.IntermediateRoot(true).Bytes()
+}
+
+// Stop closes the chains of all the nodes
+func (net *Network) Stop() {
+	for _, n := range net.Nodes {
+		if n.rpc != nil {
+			n.rpc.Close()
+			n.rpcServer.Stop()
+		}
+		n.Backend.Ethereum().Stop()
+	}
+}
+
+// Height is the last committed height
+func (net *Network) Height() int64 {
+	return net.height
+}
+
+// At registers fn to run right before the block at height is proposed, e.g. to switch a fork setting
+func (net *Network) At(height int64, fn func(*Network)) {
+	net.hooks[height] = append(net.hooks[height], fn)
+}
+
+// AtCommit registers fn to run right before each node commits the block at height
+func (net *Network) AtCommit(height int64, fn func(net *Network, i int)) {
+	net.commitHooks[height] = append(net.commitHooks[height], fn)
+}
+
+// SetAbsent makes val miss (or sign again) the votes reported from the next block on
+func (net *Network) SetAbsent(val *Validator, absent bool) {
+	net.absent[val.TmAddress()] = absent
+}
+
+// Slash reports a duplicate vote of val in the next block
+func (net *Network) Slash(val *Validator) {
+	power := int64(1)
+	for _, update := range net.valSets[net.height+1] {
+		if bytes.Equal(update.PubKey.Data, val.PubKey().Data) {
+			power = update.Power
+		}
+	}
+	net.evidences = append(net.evidences, abciTypes.Evidence{
+		Type:             tmTypes.ABCIEvidenceTypeDuplicateVote,
+		Validator:        abciTypes.Validator{Address: val.TmKey.PubKey().Address(), Power: power},
+		Height:           net.height,
+		Time:             net.time,
+		TotalVotingPower: net.totalPower(net.height + 1),
+	})
+}
+
+// ValidatorSet returns the validators signing the block at height
+func (net *Network) ValidatorSet(height int64) []abciTypes.ValidatorUpdate {
+	return net.valSets[height]
+}
+
+func (net *Network) totalPower(height int64) int64 {
+	total := int64(0)
+	for _, update := range net.valSets[height] {
+		total += update.Power
+	}
+	return total
+}
+
+// Tx signs a tx of from with the next nonce of its account
+func (net *Network) Tx(from *ecdsa.PrivateKey, to common.Address, value *big.Int, data []byte) *ethTypes.Transaction {
+	return net.PricedTx(from, to, value, big.NewInt(0), data)
+}
+
+// PricedTx is Tx paying gasPrice
+func (net *Network) PricedTx(from *ecdsa.PrivateKey, to common.Address, value *big.Int, gasPrice *big.Int,
+	data []byte) *ethTypes.Transaction {
+	return net.sign(from, ethTypes.NewTransaction(net.nextNonce(from), to, value, 1000000, gasPrice, data))
+}
+
+// ContractTx signs a contract creation of from running the init code
+func (net *Network) ContractTx(from *ecdsa.PrivateKey, code []byte) *ethTypes.Transaction {
+	return net.sign(from, ethTypes.NewContractCreation(net.nextNonce(from), big.NewInt(0), 1000000, big.NewInt(0), code))
+}
+
+func (net *Network) nextNonce(from *ecdsa.PrivateKey) uint64 {
+	address := crypto.PubkeyToAddress(from.PublicKey)
+	nonce, found := net.nonces[address]
+	if !found {
+		state, err := net.Nodes[0].Backend.Ethereum().BlockChain().State()
+		if err != nil {
+			net.t.Fatalf("state: %v", err)
+		}
+		nonce = state.GetNonce(address)
+	}
+	net.nonces[address] = nonce + 1
+	return nonce
+}
+
+func (net *Network) sign(from *ecdsa.PrivateKey, tx *ethTypes.Transaction) *ethTypes.Transaction {
+	signed, err := ethTypes.SignTx(tx, net.signer, from)
+	if err != nil {
+		net.t.Fatalf("sign tx: %v", err)
+	}
+	return signed
+}
+
+// BondTx bonds val with value, its PosItem is inserted in the PosTable of the next epoch
+func (net *Network) BondTx(val *Validator, value *big.Int) *ethTypes.Transaction {
+	data, err := json.Marshal(struct {
+		PubKey       abciTypes.PubKey `json:"pub_key"`
+		Beneficiary  string           `json:"beneficiary"`
+		BlsKeyString string           `json:"bls_key_string"`
+	}{val.PubKey(), val.Beneficiary.Hex(), val.BlsKeyString()})
+	if err != nil {
+		net.t.Fatalf("bond data: %v", err)
+	}
+	return net.Tx(val.EthKey, txfilter.SendToLock, value, data)
+}
+
+// UnbondTx removes the PosItem of val
+func (net *Network) UnbondTx(val *Validator) *ethTypes.Transaction {
+	return net.Tx(val.EthKey, txfilter.SendToUnlock, big.NewInt(0), nil)
+}
+
+// SystemTx signs a system tx of from calling method with the json encoding of params
+func (net *Network) SystemTx(from *ecdsa.PrivateKey, method string, params interface{}) *ethTypes.Transaction {
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		net.t.Fatalf("system tx params: %v", err)
+	}
+	data, err := json.Marshal(emtTypes.SystemTx{Method: method, Params: paramsJSON})
+	if err != nil {
+		net.t.Fatalf("system tx: %v", err)
+	}
+	return net.Tx(from, emtTypes.SystemTxAddress, big.NewInt(0), data)
+}
+
+// CheckTx runs a new tx through the CheckTx of node i
+func (net *Network) CheckTx(i int, tx *ethTypes.Transaction) abciTypes.ResponseCheckTx {
+	txBytes, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		net.t.Fatalf("encode tx: %v", err)
+	}
+	return net.Nodes[i].App.CheckTx(abciTypes.RequestCheckTx{Tx: txBytes, Type: abciTypes.CheckTxType_New})
+}
+
+// NextBlock runs one height with txs on every node and fails the test if the nodes disagree
+func (net *Network) NextBlock(txs ...*ethTypes.Transaction) *Block {
+	height := net.height + 1
+	for _, hook := range net.hooks[height] {
+		hook(net)
+	}
+	delete(net.hooks, height)
+	defer delete(net.commitHooks, height)
+	net.time = net.time.Add(time.Second)
+
+	valSet := net.valSets[height]
+	if len(valSet) == 0 {
+		net.t.Fatalf("no validator at height %d", height)
+	}
+	proposer := sortedAddresses(valSet)[int(height)%len(valSet)]
+	var votes []abciTypes.VoteInfo
+	for _, update := range net.valSets[height-1] {
+		address := pubKeyAddress(update.PubKey)
+		votes = append(votes, abciTypes.VoteInfo{
+			Validator:       abciTypes.Validator{Address: address, Power: update.Power},
+			SignedLastBlock: !net.absent[fmt.Sprintf("%X", address)],
+		})
+	}
+	beginBlock := abciTypes.RequestBeginBlock{
+		Header: abciTypes.Header{
+			Version:         abciTypes.Version{App: net.appVersion},
+			ChainID:         fmt.Sprintf("apptest-%d", net.chainID),
+			Height:          height,
+			Time:            net.time,
+			ProposerAddress: proposer,
+		},
+		LastCommitInfo:      abciTypes.LastCommitInfo{Votes: votes},
+		ByzantineValidators: net.evidences,
+	}
+	net.evidences = nil
+	seed := sha256.Sum256(int64Bytes(height))
+	endBlock := abciTypes.RequestEndBlock{Height: height, Seed: seed[:]}
+
+	var blocks []*Block
+	for i, n := range net.Nodes {
+		block := &Block{Height: height, Proposer: fmt.Sprintf("%X", proposer)}
+		block.BeginBlock = n.App.BeginBlock(beginBlock)
+		for _, tx := range txs {
+			txBytes, err := rlp.EncodeToBytes(tx)
+			if err != nil {
+				net.t.Fatalf("encode tx: %v", err)
+			}
+			block.DeliverTxs = append(block.DeliverTxs, n.App.DeliverTx(abciTypes.RequestDeliverTx{Tx: txBytes}))
+		}
+		block.EndBlock = n.App.EndBlock(endBlock)
+		for _, hook := range net.commitHooks[height] {
+			hook(net, i)
+		}
+		block.AppHash = n.App.Commit().Data
+		if i > 0 {
+			net.compare(i, blocks[0], block)
+		}
+		blocks = append(blocks, block)
+	}
+
+	net.height = height
+	if blocks[0].EndBlock.AppVersion != 0 {
+		net.appVersion = blocks[0].EndBlock.AppVersion
+	}
+	net.valSets[height+2] = applyValidatorUpdates(net.valSets[height+1], blocks[0].EndBlock.ValidatorUpdates)
+	return blocks[0]
+}
+
+// NextBlocks runs count empty blocks
+func (net *Network) NextBlocks(count int) *Block {
+	var block *Block
+	for i := 0; i < count; i++ {
+		block = net.NextBlock()
+	}
+	return block
+}
+
+// NextEpoch runs empty blocks up to and including the next epoch switch height
+func (net *Network) NextEpoch() *Block {
+	block := net.NextBlock()
+	for block.Height%txfilter.EpochBlocks != 0 {
+		block = net.NextBlock()
+	}
+	return block
+}
+
+func (net *Network) compare(i int, expected *Block, got *Block) {
+	if len(expected.DeliverTxs) != len(got.DeliverTxs) {
+		net.t.Fatalf("height %d: node %d delivered %d txs, node 0 %d", got.Height, i, len(got.DeliverTxs), len(expected.DeliverTxs))
+	}
+	for j := range expected.DeliverTxs {
+		if expected.DeliverTxs[j].Code != got.DeliverTxs[j].Code {
+			net.t.Fatalf("height %d: tx %d got code %d on node %d and %d on node 0 (%v / %v)", got.Height, j,
+				got.DeliverTxs[j].Code, i, expected.DeliverTxs[j].Code, got.DeliverTxs[j].Log, expected.DeliverTxs[j].Log)
+		}
+	}
+	if !validatorUpdatesEqual(expected.EndBlock.ValidatorUpdates, got.EndBlock.ValidatorUpdates) {
+		net.t.Fatalf("height %d: node %d validator updates %v differ from node 0 %v", got.Height, i,
+			got.EndBlock.ValidatorUpdates, expected.EndBlock.ValidatorUpdates)
+	}
+	if !bytes.Equal(expected.AppHash, got.AppHash) {
+		net.t.Fatalf("height %d: node %d app hash %X differs from node 0 %X", got.Height, i, got.AppHash, expected.AppHash)
+	}
+}
+
+// applyValidatorUpdates returns the set after the updates, a zero power removes the validator
+func applyValidatorUpdates(set []abciTypes.ValidatorUpdate, updates []abciTypes.ValidatorUpdate) []abciTypes.ValidatorUpdate {
+	powers := make(map[string]abciTypes.ValidatorUpdate)
+	for _, update := range set {
+		powers[string(update.PubKey.Data)] = update
+	}
+	for _, update := range updates {
+		if update.Power == 0 {
+			delete(powers, string(update.PubKey.Data))
+		} else {
+			powers[string(update.PubKey.Data)] = update
+		}
+	}
+	next := make([]abciTypes.ValidatorUpdate, 0, len(powers))
+	for _, update := range powers {
+		next = append(next, update)
+	}
+	sort.Slice(next, func(i, j int) bool {
+		return bytes.Compare(pubKeyAddress(next[i].PubKey), pubKeyAddress(next[j].PubKey)) < 0
+	})
+	return next
+}
+
+func validatorUpdatesEqual(a []abciTypes.ValidatorUpdate, b []abciTypes.ValidatorUpdate) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i].PubKey.Data, b[i].PubKey.Data) || a[i].Power != b[i].Power {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedAddresses(set []abciTypes.ValidatorUpdate) [][]byte {
+	addresses := make([][]byte, 0, len(set))
+	for _, update := range set {
+		addresses = append(addresses, pubKeyAddress(update.PubKey))
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i], addresses[j]) < 0
+	})
+	return addresses
+}
+
+func pubKeyAddress(pubKey abciTypes.PubKey) []byte {
+	tmPubKey, err := tmTypes.PB2TM.PubKey(pubKey)
+	if err != nil {
+		panic(err)
+	}
+	return tmPubKey.Address()
+}
+
+func int64Bytes(i int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(i))
+	return b
+}
@@ -0,0 +1,831 @@
+package apptest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	goEthereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/txfilter"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+	tmCrypto "github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	tmTypes "github.com/tendermint/tendermint/types"
+
+	"github.com/DTFN/dtfn/ethereum"
+	emtTypes "github.com/DTFN/dtfn/types"
+	"github.com/DTFN/dtfn/version"
+)
+
+func TestNetworkEpochSwitch(t *testing.T) {
+	net := NewNetwork(t, DefaultConfig())
+	defer net.Stop()
+
+	block := net.NextEpoch()
+	require.Equal(t, int64(0), block.Height%txfilter.EpochBlocks)
+	block = net.NextEpoch()
+	require.Equal(t, 2*txfilter.EpochBlocks, block.Height)
+	require.NotEmpty(t, net.ValidatorSet(net.Height()+1))
+}
+
+func TestNetworkSlash(t *testing.T) {
+	net := NewNetwork(t, DefaultConfig())
+	defer net.Stop()
+
+	net.NextBlocks(2)
+	evil := net.Validators[1]
+	net.Slash(evil)
+	block := net.NextBlock()
+
+	events := block.Events("slash")
+	require.Len(t, events, 1)
+	attributes := make(map[string]string)
+	for _, attribute := range events[0].Attributes {
+		attributes[string(attribute.Key)] = string(attribute.Value)
+	}
+	require.Equal(t, evil.TmAddress(), attributes["validator"])
+	require.Equal(t, strings.ToLower(evil.Signer().Hex()), attributes["signer"])
+	require.Equal(t, emtTypes.SlashActionRemoved, attributes["posTableAction"])
+	for _, n := range net.Nodes {
+		strategy := n.App.GetStrategy()
+		_, bonded := strategy.NextEpochValData.PosTable.PosItemMap[evil.Signer()]
+		require.False(t, bonded)
+		state, err := n.Backend.Ethereum().BlockChain().State()
+		require.NoError(t, err)
+		require.Equal(t, 0, state.GetBalance(evil.Signer()).Sign())
+
+		// the slashed amount goes to the coinbase of the block
+		coinbase := n.Backend.Ethereum().BlockChain().GetHeaderByNumber(uint64(block.Height)).Coinbase
+		require.Equal(t, strings.ToLower(coinbase.Hex()), attributes["destination"])
+
+		// the journal is served by the Slashes query once committed
+		res := n.App.Query(abciTypes.RequestQuery{
+			Path: "Slashes",
+			Data: []byte(`{"params":[` + strconv.FormatInt(block.Height, 10) + `]}`),
+		})
+		require.Equal(t, abciTypes.CodeTypeOK, res.Code, res.Log)
+		var records []*emtTypes.SlashRecord
+		require.NoError(t, json.Unmarshal(res.Value, &records))
+		require.Len(t, records, 1)
+		require.Equal(t, block.Height, records[0].Height)
+		require.Equal(t, evil.Signer(), records[0].Signer)
+		require.Equal(t, &coinbase, records[0].Destination)
+		require.Equal(t, attributes["amount"], records[0].Amount.String())
+	}
+}
+
+func TestNetworkBond(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Candidates = 1
+	net := NewNetwork(t, cfg)
+	defer net.Stop()
+
+	candidate := net.Candidates[0]
+	block := net.NextBlock(net.BondTx(candidate, big.NewInt(0)))
+	require.Equal(t, abciTypes.CodeTypeOK, block.DeliverTxs[0].Code, block.DeliverTxs[0].Log)
+	for _, n := range net.Nodes {
+		_, bonded := n.App.GetStrategy().NextEpochValData.PosTable.PosItemMap[candidate.Signer()]
+		require.True(t, bonded)
+	}
+
+	net.NextEpoch()
+	for _, n := range net.Nodes {
+		_, bonded := n.App.GetStrategy().CurrEpochValData.PosTable.PosItemMap[candidate.Signer()]
+		require.True(t, bonded)
+	}
+}
+
+func TestNetworkForkHeight(t *testing.T) {
+	user, _ := crypto.GenerateKey()
+	cfg := DefaultConfig()
+	cfg.Accounts = map[common.Address]*big.Int{crypto.PubkeyToAddress(user.PublicKey): big.NewInt(1000000000000)}
+	net := NewNetwork(t, cfg)
+	defer net.Stop()
+	defer func() {
+		version.MinGasPriceHeight = 0
+		version.MinGasPrice = big.NewInt(0)
+	}()
+
+	// the consensus minimum gas price applies from height 5
+	net.At(3, func(*Network) {
+		version.MinGasPriceHeight = 5
+		version.MinGasPrice = big.NewInt(1)
+	})
+	receiver := common.HexToAddress("0x0000000000000000000000000000000000000abc")
+	net.NextBlocks(3)
+	block := net.NextBlock(net.Tx(user, receiver, big.NewInt(1), nil))
+	require.Equal(t, abciTypes.CodeTypeOK, block.DeliverTxs[0].Code, block.DeliverTxs[0].Log)
+
+	block = net.NextBlock(net.Tx(user, receiver, big.NewInt(1), nil))
+	require.Equal(t, uint32(emtTypes.CodeInsufficientFee), block.DeliverTxs[0].Code)
+}
+
+func TestNetworkMinGasPrice(t *testing.T) {
+	user, _ := crypto.GenerateKey()
+	userAddress := crypto.PubkeyToAddress(user.PublicKey)
+	cfg := DefaultConfig()
+	cfg.Accounts = map[common.Address]*big.Int{userAddress: big.NewInt(1000000000000)}
+	net := NewNetwork(t, cfg)
+	defer net.Stop()
+	version.MinGasPriceHeight = 5
+	version.MinGasPrice = big.NewInt(1)
+	defer func() {
+		version.MinGasPriceHeight = 0
+		version.MinGasPrice = big.NewInt(0)
+	}()
+	net.Nodes[0].App.SetMinGasPrice(big.NewInt(2))
+	receiver := common.HexToAddress("0x0000000000000000000000000000000000000abc")
+	// checkTx runs tx on node i without using its nonce
+	checkTx := func(i int, gasPrice int64) abciTypes.ResponseCheckTx {
+		res := net.CheckTx(i, net.PricedTx(user, receiver, big.NewInt(1), big.NewInt(gasPrice), nil))
+		delete(net.nonces, userAddress)
+		return res
+	}
+
+	// before the fork height only the node minimum applies, and only to CheckTx
+	net.NextBlocks(2)
+	res := checkTx(0, 1)
+	require.Equal(t, uint32(emtTypes.CodeInsufficientFee), res.Code)
+	require.Contains(t, res.Log, "node minimum")
+	res = checkTx(1, 0)
+	require.Equal(t, abciTypes.CodeTypeOK, res.Code, res.Log)
+	block := net.NextBlock(net.Tx(user, receiver, big.NewInt(1), nil))
+	require.Equal(t, abciTypes.CodeTypeOK, block.DeliverTxs[0].Code, block.DeliverTxs[0].Log)
+
+	// CheckTx applies the consensus minimum of the next height
+	net.NextBlock()
+	res = checkTx(1, 0)
+	require.Equal(t, uint32(emtTypes.CodeInsufficientFee), res.Code)
+	require.Contains(t, res.Log, "consensus minimum")
+	res = checkTx(1, 1)
+	require.Equal(t, abciTypes.CodeTypeOK, res.Code, res.Log)
+
+	block = net.NextBlock(net.Tx(user, receiver, big.NewInt(1), nil))
+	require.Equal(t, int64(5), block.Height)
+	require.Equal(t, uint32(emtTypes.CodeInsufficientFee), block.DeliverTxs[0].Code)
+	delete(net.nonces, userAddress)
+	// the node minimum is not a consensus rule
+	block = net.NextBlock(net.PricedTx(user, receiver, big.NewInt(1), big.NewInt(1), nil))
+	require.Equal(t, abciTypes.CodeTypeOK, block.DeliverTxs[0].Code, block.DeliverTxs[0].Log)
+}
+
+func TestNetworkSubscriptions(t *testing.T) {
+	user, _ := crypto.GenerateKey()
+	cfg := DefaultConfig()
+	cfg.Accounts = map[common.Address]*big.Int{crypto.PubkeyToAddress(user.PublicKey): big.NewInt(1000000000000)}
+	net := NewNetwork(t, cfg)
+	defer net.Stop()
+	version.ReceiptBlockHashHeight = 1
+	defer func() { version.ReceiptBlockHashHeight = 0 }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := ethclient.NewClient(net.Nodes[0].RPC())
+	heads := make(chan *ethTypes.Header, 10)
+	headSub, err := client.SubscribeNewHead(ctx, heads)
+	require.NoError(t, err)
+	defer headSub.Unsubscribe()
+	logs := make(chan ethTypes.Log, 10)
+	logSub, err := client.SubscribeFilterLogs(ctx, goEthereum.FilterQuery{}, logs)
+	require.NoError(t, err)
+	defer logSub.Unsubscribe()
+
+	// PUSH1 0 PUSH1 0 LOG0 STOP, the init code emits an empty log
+	block := net.NextBlock(net.ContractTx(user, common.FromHex("0x60006000a000")))
+	require.Equal(t, abciTypes.CodeTypeOK, block.DeliverTxs[0].Code, block.DeliverTxs[0].Log)
+	committed := net.Nodes[0].Backend.Ethereum().BlockChain().CurrentBlock()
+	require.Equal(t, uint64(block.Height), committed.NumberU64())
+
+	select {
+	case head := <-heads:
+		require.Equal(t, committed.Hash(), head.Hash())
+	case err := <-headSub.Err():
+		t.Fatalf("head subscription: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no head for block %v", block.Height)
+	}
+	select {
+	case l := <-logs:
+		require.Equal(t, committed.NumberU64(), l.BlockNumber)
+		require.Equal(t, committed.Hash(), l.BlockHash)
+		require.Equal(t, committed.Transactions()[0].Hash(), l.TxHash)
+	case err := <-logSub.Err():
+		t.Fatalf("log subscription: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no log for block %v", block.Height)
+	}
+
+	// a block without txs still notifies the head
+	net.NextBlock()
+	select {
+	case head := <-heads:
+		require.Equal(t, big.NewInt(block.Height+1), head.Number)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no head for block %v", block.Height+1)
+	}
+}
+
+func TestNetworkPendingState(t *testing.T) {
+	user, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(user.PublicKey)
+	cfg := DefaultConfig()
+	cfg.Accounts = map[common.Address]*big.Int{address: big.NewInt(1000000000000)}
+	net := NewNetwork(t, cfg)
+	defer net.Stop()
+
+	ctx := context.Background()
+	n := net.Nodes[0]
+	client := ethclient.NewClient(n.RPC())
+	receiver := common.HexToAddress("0x0000000000000000000000000000000000000abc")
+	checkTx := func(tx *ethTypes.Transaction) {
+		txBytes, err := rlp.EncodeToBytes(tx)
+		require.NoError(t, err)
+		res := n.App.CheckTx(abciTypes.RequestCheckTx{Tx: txBytes, Type: abciTypes.CheckTxType_New})
+		require.Equal(t, abciTypes.CodeTypeOK, res.Code, res.Log)
+	}
+
+	// two txs in the mempool
+	first := net.Tx(user, receiver, big.NewInt(1), nil)
+	second := net.Tx(user, receiver, big.NewInt(1), nil)
+	checkTx(first)
+	checkTx(second)
+	nonce, err := client.PendingNonceAt(ctx, address)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), nonce)
+	nonce, err = client.NonceAt(ctx, address, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), nonce)
+	balance, err := client.PendingBalanceAt(ctx, receiver)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(2), balance)
+
+	// the first one is committed, the recheck keeps the second one pending
+	net.NextBlock(first)
+	checkTx(second)
+	nonce, err = client.PendingNonceAt(ctx, address)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), nonce)
+	nonce, err = client.NonceAt(ctx, address, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), nonce)
+}
+
+func TestNetworkDtfnRPC(t *testing.T) {
+	net := NewNetwork(t, DefaultConfig())
+	defer net.Stop()
+	net.NextBlocks(2)
+	client := net.Nodes[0].RPC()
+
+	var peers hexutil.Uint
+	require.NoError(t, client.Call(&peers, "net_peerCount"))
+	require.Equal(t, hexutil.Uint(0), peers)
+
+	var epoch ethereum.EpochInfo
+	require.NoError(t, client.Call(&epoch, "dtfn_getEpochInfo"))
+	require.Equal(t, net.Height(), epoch.Height)
+	require.Equal(t, txfilter.EpochBlocks, epoch.NextEpochHeight)
+
+	var validators []*ethereum.ValidatorResult
+	require.NoError(t, client.Call(&validators, "dtfn_getCurrentValidators"))
+	require.NotEmpty(t, validators)
+
+	var posTable ethereum.PosTableResult
+	require.NoError(t, client.Call(&posTable, "dtfn_getPosTable"))
+	for _, val := range net.Validators {
+		require.Contains(t, posTable.PosItemMap, val.Signer())
+	}
+
+	// the harness has no tendermint node
+	var status *ethereum.SyncStatus
+	require.Error(t, client.Call(&status, "dtfn_syncStatus"))
+	var syncing interface{}
+	require.NoError(t, client.Call(&syncing, "eth_syncing"))
+	require.Equal(t, false, syncing)
+}
+
+func TestNetworkHealth(t *testing.T) {
+	net := NewNetwork(t, DefaultConfig())
+	defer net.Stop()
+	net.NextBlocks(2)
+	backend := net.Nodes[0].Backend
+
+	checks := make(map[string]*ethereum.HealthCheck)
+	report := backend.Readiness()
+	for _, check := range report.Checks {
+		checks[check.Name] = check
+	}
+	require.Len(t, checks, 7)
+	require.True(t, checks["eth_state"].Healthy, checks["eth_state"].Message)
+	require.True(t, checks["latest_commit"].Healthy, checks["latest_commit"].Message)
+	require.True(t, checks["txpool_backlog"].Healthy, checks["txpool_backlog"].Message)
+	require.True(t, checks["synced"].Healthy, checks["synced"].Message)
+	require.True(t, checks["tx_intake"].Healthy, checks["tx_intake"].Message)
+	// the harness runs neither the tendermint rpc nor the Broadcaster
+	require.False(t, checks["tendermint_rpc"].Healthy)
+	require.False(t, checks["tx_broadcast_loop"].Healthy)
+	require.False(t, report.Healthy)
+	// neither of them means the process is dead
+	liveness := backend.Liveness()
+	require.True(t, liveness.Healthy)
+	require.Len(t, liveness.Checks, 1)
+	require.Equal(t, "eth_state", liveness.Checks[0].Name)
+
+	// a shutting down node is not ready
+	backend.StopTxIntake()
+	for _, check := range backend.Readiness().Checks {
+		if check.Name == "tx_intake" {
+			require.False(t, check.Healthy)
+		}
+	}
+}
+
+func TestNetworkTxHashes(t *testing.T) {
+	user, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(user.PublicKey)
+	to := common.HexToAddress("0x00000000000000000000000000000000000000Ab")
+	cfg := DefaultConfig()
+	cfg.Accounts = map[common.Address]*big.Int{from: big.NewInt(1000000000000)}
+	net := NewNetwork(t, cfg)
+	defer net.Stop()
+
+	tx := net.Tx(user, to, big.NewInt(1), nil)
+	block := net.NextBlock(tx)
+	require.Equal(t, abciTypes.CodeTypeOK, block.DeliverTxs[0].Code, block.DeliverTxs[0].Log)
+	ethEvents := block.Events("eth")
+	require.Len(t, ethEvents, 1)
+	attributes := make(map[string]string)
+	for _, pair := range ethEvents[0].Attributes {
+		attributes[string(pair.Key)] = string(pair.Value)
+	}
+	require.Equal(t, tx.Hash().Hex(), attributes["hash"])
+	require.Equal(t, strings.ToLower(from.Hex()), attributes["from"])
+	require.Equal(t, strings.ToLower(to.Hex()), attributes["to"])
+
+	// dtfn_getTransactionByHash answers both hashes with the eth tx
+	txBytes, err := rlp.EncodeToBytes(tx)
+	require.NoError(t, err)
+	tmHash := tmTypes.Tx(txBytes).Hash()
+	client := net.Nodes[0].RPC()
+	for _, hash := range []common.Hash{tx.Hash(), common.BytesToHash(tmHash)} {
+		var lookup *ethereum.TxLookup
+		require.NoError(t, client.Call(&lookup, "dtfn_getTransactionByHash", hash))
+		require.NotNil(t, lookup, "no tx for %v", hash.Hex())
+		require.Equal(t, tx.Hash(), lookup.EthHash)
+		require.Equal(t, hexutil.Bytes(tmHash), lookup.TmHash)
+		require.Equal(t, hexutil.Uint64(block.Height), lookup.BlockNumber)
+	}
+	var lookup *ethereum.TxLookup
+	require.NoError(t, client.Call(&lookup, "dtfn_getTransactionByHash", common.HexToHash("0x01")))
+	require.Nil(t, lookup)
+
+	var result map[string]interface{}
+	require.NoError(t, client.Call(&result, "eth_getTransactionByHash", tx.Hash()))
+	require.Equal(t, tx.Hash().Hex(), result["hash"])
+}
+
+// setAdmin makes admin the PPChainAdmin of the version config and of the running app,
+// the returned func restores the previous admin
+func setAdmin(admin common.Address) func() {
+	ppChainAdmin, ppChainPrivateAdmin, txfilterAdmin := version.PPChainAdmin, version.PPChainPrivateAdmin, txfilter.PPChainAdmin
+	version.PPChainAdmin = admin.Hex()
+	version.PPChainPrivateAdmin = admin.Hex()
+	txfilter.PPChainAdmin = admin
+	return func() {
+		version.PPChainAdmin, version.PPChainPrivateAdmin, txfilter.PPChainAdmin = ppChainAdmin, ppChainPrivateAdmin, txfilterAdmin
+	}
+}
+
+func TestNetworkTxPolicy(t *testing.T) {
+	admin, _ := crypto.GenerateKey()
+	deployer, _ := crypto.GenerateKey()
+	user, _ := crypto.GenerateKey()
+	deployerAddress := crypto.PubkeyToAddress(deployer.PublicKey)
+	userAddress := crypto.PubkeyToAddress(user.PublicKey)
+	cfg := DefaultConfig()
+	cfg.Accounts = map[common.Address]*big.Int{
+		crypto.PubkeyToAddress(admin.PublicKey): big.NewInt(1000000000000),
+		deployerAddress:                         big.NewInt(1000000000000),
+		userAddress:                             big.NewInt(1000000000000),
+	}
+	net := NewNetwork(t, cfg)
+	defer net.Stop()
+	defer setAdmin(crypto.PubkeyToAddress(admin.PublicKey))()
+	requireOK := func(block *Block) {
+		for _, res := range block.DeliverTxs {
+			require.Equal(t, abciTypes.CodeTypeOK, res.Code, res.Log)
+		}
+	}
+	requireUnauthorized := func(block *Block, from common.Address) {
+		require.Equal(t, uint32(emtTypes.CodeUnauthorized), block.DeliverTxs[0].Code, block.DeliverTxs[0].Log)
+		// the rejected tx did not use its nonce
+		delete(net.nonces, from)
+	}
+	code := []byte{0x00} // STOP
+
+	// only the deployer may create contracts
+	requireOK(net.NextBlock(
+		net.SystemTx(admin, emtTypes.SystemTxSetDeployRestricted, emtTypes.SetDeployRestrictedParams{Restricted: true}),
+		net.SystemTx(admin, emtTypes.SystemTxAddDeployer, emtTypes.AccountParams{Account: deployerAddress})))
+	requireUnauthorized(net.NextBlock(net.ContractTx(user, code)), userAddress)
+	deployTx := net.ContractTx(deployer, code)
+	contract := crypto.CreateAddress(deployerAddress, deployTx.Nonce())
+	requireOK(net.NextBlock(deployTx))
+
+	// only the deployer may call the contract, the user may send up to 100
+	requireOK(net.NextBlock(
+		net.SystemTx(admin, emtTypes.SystemTxSetContractCallers, emtTypes.SetContractCallersParams{
+			Contract: contract, Callers: []common.Address{deployerAddress}}),
+		net.SystemTx(admin, emtTypes.SystemTxSetValueLimit, emtTypes.SetValueLimitParams{
+			Account: userAddress, Limit: big.NewInt(100)})))
+	requireUnauthorized(net.NextBlock(net.Tx(user, contract, big.NewInt(0), nil)), userAddress)
+	requireOK(net.NextBlock(net.Tx(deployer, contract, big.NewInt(0), nil)))
+	receiver := common.HexToAddress("0x0000000000000000000000000000000000000abc")
+	requireUnauthorized(net.NextBlock(net.Tx(user, receiver, big.NewInt(101), nil)), userAddress)
+	requireOK(net.NextBlock(net.Tx(user, receiver, big.NewInt(100), nil)))
+
+	// the policy is read back from the state by a restarted node
+	net.Restart(0)
+	txPolicy := net.Nodes[0].App.GetStrategy().TxPolicy
+	require.True(t, txPolicy.DeployRestricted)
+	require.Error(t, txPolicy.Check(userAddress, nil, big.NewInt(0)))
+	require.Error(t, txPolicy.Check(userAddress, &contract, big.NewInt(0)))
+	require.NoError(t, txPolicy.Check(deployerAddress, &contract, big.NewInt(0)))
+	requireUnauthorized(net.NextBlock(net.Tx(user, receiver, big.NewInt(101), nil)), userAddress)
+}
+
+func TestNetworkAdminSet(t *testing.T) {
+	admin, _ := crypto.GenerateKey()
+	adminAddress := crypto.PubkeyToAddress(admin.PublicKey)
+	cfg := DefaultConfig()
+	cfg.Accounts = map[common.Address]*big.Int{adminAddress: big.NewInt(1000000000000)}
+	var members []*ecdsa.PrivateKey
+	var memberAddresses []common.Address
+	for i := 0; i < 3; i++ {
+		member, _ := crypto.GenerateKey()
+		members = append(members, member)
+		memberAddresses = append(memberAddresses, crypto.PubkeyToAddress(member.PublicKey))
+		cfg.Accounts[memberAddresses[i]] = big.NewInt(1000000000000)
+	}
+	net := NewNetwork(t, cfg)
+	defer net.Stop()
+	defer setAdmin(adminAddress)()
+	adminSet := func() *emtTypes.AdminSet {
+		return net.Nodes[0].App.GetStrategy().AdminSet
+	}
+	requireCode := func(block *Block, code uint32) {
+		require.Equal(t, code, block.DeliverTxs[0].Code, block.DeliverTxs[0].Log)
+	}
+	propose := func(from *ecdsa.PrivateKey, method string, params interface{}) *ethTypes.Transaction {
+		paramsJSON, err := json.Marshal(params)
+		require.NoError(t, err)
+		return net.SystemTx(from, emtTypes.SystemTxPropose, emtTypes.ProposeParams{Method: method, Params: paramsJSON})
+	}
+	systxAttributes := func(block *Block) map[string]string {
+		events := block.Events("systx")
+		require.Len(t, events, 1)
+		attributes := make(map[string]string)
+		for _, pair := range events[0].Attributes {
+			attributes[string(pair.Key)] = string(pair.Value)
+		}
+		return attributes
+	}
+
+	// 2 of 3 members administrate the chain, the single admin no longer does
+	requireCode(net.NextBlock(net.SystemTx(admin, emtTypes.SystemTxSetAdminSet, emtTypes.SetAdminSetParams{
+		Members: memberAddresses, Threshold: 2})), abciTypes.CodeTypeOK)
+	require.True(t, adminSet().Enabled())
+	requireCode(net.NextBlock(net.SystemTx(admin, emtTypes.SystemTxSetDeployRestricted,
+		emtTypes.SetDeployRestrictedParams{Restricted: true})), uint32(emtTypes.CodeUnauthorized))
+	delete(net.nonces, adminAddress)
+
+	// a proposal runs once it has the approvals of the threshold
+	block := net.NextBlock(propose(members[0], emtTypes.SystemTxSetDeployRestricted,
+		emtTypes.SetDeployRestrictedParams{Restricted: true}))
+	requireCode(block, abciTypes.CodeTypeOK)
+	attributes := systxAttributes(block)
+	require.Equal(t, "1", attributes["approvals"])
+	require.Empty(t, attributes["executed"])
+	id, err := strconv.ParseUint(attributes["proposal"], 10, 64)
+	require.NoError(t, err)
+	require.False(t, net.Nodes[0].App.GetStrategy().TxPolicy.DeployRestricted)
+
+	for _, from := range []*ecdsa.PrivateKey{members[0], admin} {
+		requireCode(net.NextBlock(net.SystemTx(from, emtTypes.SystemTxApprove, emtTypes.ApproveParams{ID: id})),
+			uint32(emtTypes.CodeUnauthorized))
+		delete(net.nonces, crypto.PubkeyToAddress(from.PublicKey))
+	}
+	block = net.NextBlock(net.SystemTx(members[1], emtTypes.SystemTxApprove, emtTypes.ApproveParams{ID: id}))
+	requireCode(block, abciTypes.CodeTypeOK)
+	attributes = systxAttributes(block)
+	require.Equal(t, "2", attributes["approvals"])
+	require.Equal(t, emtTypes.SystemTxSetDeployRestricted, attributes["executed"])
+	require.Empty(t, adminSet().Proposals)
+	for _, n := range net.Nodes {
+		require.True(t, n.App.GetStrategy().TxPolicy.DeployRestricted)
+	}
+
+	// the auth txs of the admin need an approval of the set, which expires
+	authTx := net.Tx(admin, txfilter.SendToAuth, big.NewInt(0), nil)
+	delete(net.nonces, adminAddress)
+	requireCode(net.NextBlock(authTx), uint32(emtTypes.CodeUnauthorized))
+	expiryHeight := net.Height() + 4
+	block = net.NextBlock(propose(members[2], emtTypes.SystemTxApproveTx,
+		emtTypes.ApproveTxParams{Hash: authTx.Hash(), ExpiryHeight: expiryHeight}))
+	id, err = strconv.ParseUint(systxAttributes(block)["proposal"], 10, 64)
+	require.NoError(t, err)
+	net.NextBlock(net.SystemTx(members[0], emtTypes.SystemTxApprove, emtTypes.ApproveParams{ID: id}))
+	require.True(t, adminSet().IsTxApproved(authTx.Hash(), expiryHeight))
+	require.False(t, adminSet().IsTxApproved(authTx.Hash(), expiryHeight+1))
+
+	for net.Height() < expiryHeight {
+		net.NextBlock()
+	}
+	for _, n := range net.Nodes {
+		require.Empty(t, n.App.GetStrategy().AdminSet.ApprovedTxs)
+	}
+	requireCode(net.NextBlock(authTx), uint32(emtTypes.CodeUnauthorized))
+}
+
+func TestNetworkAdminRotation(t *testing.T) {
+	admin, _ := crypto.GenerateKey()
+	newAdmin, _ := crypto.GenerateKey()
+	adminAddress := crypto.PubkeyToAddress(admin.PublicKey)
+	newAdminAddress := crypto.PubkeyToAddress(newAdmin.PublicKey)
+	cfg := DefaultConfig()
+	cfg.Accounts = map[common.Address]*big.Int{
+		adminAddress:    big.NewInt(1000000000000),
+		newAdminAddress: big.NewInt(1000000000000),
+	}
+	net := NewNetwork(t, cfg)
+	defer net.Stop()
+	defer setAdmin(adminAddress)()
+	requireCode := func(block *Block, code uint32) {
+		require.Equal(t, code, block.DeliverTxs[0].Code, block.DeliverTxs[0].Log)
+	}
+	restrictTx := func(from *ecdsa.PrivateKey) *ethTypes.Transaction {
+		return net.SystemTx(from, emtTypes.SystemTxSetDeployRestricted, emtTypes.SetDeployRestrictedParams{Restricted: true})
+	}
+
+	effectiveHeight := net.Height() + 4
+	requireCode(net.NextBlock(net.SystemTx(admin, emtTypes.SystemTxRotateAdmin, emtTypes.RotateAdminParams{
+		Role: emtTypes.AdminRolePPChainAdmin, Account: newAdminAddress, EffectiveHeight: effectiveHeight})),
+		abciTypes.CodeTypeOK)
+
+	// the old admin stays in charge up to the effective height
+	for net.Height() < effectiveHeight-1 {
+		require.Empty(t, net.NextBlock().Events("adminRotation"))
+		require.Equal(t, adminAddress, txfilter.PPChainAdmin)
+	}
+	block := net.NextBlock(restrictTx(newAdmin))
+	require.Len(t, block.Events("adminRotation"), 1)
+	require.Equal(t, newAdminAddress, txfilter.PPChainAdmin)
+	// the rotation applies from BeginBlock, the new admin signs the txs of the effective height
+	requireCode(block, abciTypes.CodeTypeOK)
+	requireCode(net.NextBlock(restrictTx(admin)), uint32(emtTypes.CodeUnauthorized))
+	delete(net.nonces, adminAddress)
+
+	// the version switch of PPChainAdmin leaves the rotated admin in place
+	for net.Height() < version.HeightArray[2] {
+		net.NextBlock()
+		require.Equal(t, newAdminAddress, txfilter.PPChainAdmin)
+	}
+
+	// a restarted node reads the rotated admin from the state instead of the version config
+	txfilter.PPChainAdmin = adminAddress
+	net.Restart(0)
+	require.Equal(t, newAdminAddress, txfilter.PPChainAdmin)
+	registry := net.Nodes[0].App.GetStrategy().AdminRegistry
+	require.Empty(t, registry.Pending)
+	require.Len(t, registry.History, 1)
+	require.Equal(t, effectiveHeight, registry.History[0].EffectiveHeight)
+	requireCode(net.NextBlock(restrictTx(newAdmin)), abciTypes.CodeTypeOK)
+}
+
+func TestNetworkValidatorTxs(t *testing.T) {
+	net := NewNetwork(t, DefaultConfig())
+	defer net.Stop()
+
+	val := net.Validators[0]
+	oldBlsKeyString := val.BlsKeyString()
+	beneficiary := common.HexToAddress("0x0000000000000000000000000000000000000b0b")
+	blsKeyJSON, _ := json.Marshal(tmTypes.BLSPubKey{Type: "Secp256k1", Address: "rotated"})
+	net.NextEpoch()
+	net.NextBlock()
+
+	block := net.NextBlock(
+		net.SystemTx(val.EthKey, emtTypes.SystemTxUpdateBeneficiary, emtTypes.UpdateBeneficiaryParams{Beneficiary: beneficiary}),
+		net.SystemTx(val.EthKey, emtTypes.SystemTxRotateBlsKey, emtTypes.RotateBlsKeyParams{BlsKeyString: string(blsKeyJSON)}))
+	for _, res := range block.DeliverTxs {
+		require.Equal(t, abciTypes.CodeTypeOK, res.Code, res.Log)
+	}
+	require.NotEqual(t, int64(0), block.Height%txfilter.EpochBlocks)
+
+	// the current epoch keeps the old values until the epoch boundary
+	for _, n := range net.Nodes {
+		strategy := n.App.GetStrategy()
+		next := strategy.NextEpochValData.PosTable.PosItemMap[val.Signer()]
+		require.Equal(t, beneficiary, next.Beneficiary)
+		require.Equal(t, string(blsKeyJSON), next.BlsKeyString)
+		curr := strategy.CurrEpochValData.PosTable.PosItemMap[val.Signer()]
+		require.Equal(t, val.Beneficiary, curr.Beneficiary)
+		require.Equal(t, oldBlsKeyString, curr.BlsKeyString)
+	}
+
+	net.NextEpoch()
+	for _, n := range net.Nodes {
+		curr := n.App.GetStrategy().CurrEpochValData.PosTable.PosItemMap[val.Signer()]
+		require.Equal(t, beneficiary, curr.Beneficiary)
+		require.Equal(t, string(blsKeyJSON), curr.BlsKeyString)
+	}
+
+	// a bls key must decode
+	block = net.NextBlock(net.SystemTx(val.EthKey, emtTypes.SystemTxRotateBlsKey, emtTypes.RotateBlsKeyParams{BlsKeyString: `{"type":1}`}))
+	require.NotEqual(t, abciTypes.CodeTypeOK, block.DeliverTxs[0].Code)
+}
+
+func TestNetworkBlockGasLimit(t *testing.T) {
+	admin, _ := crypto.GenerateKey()
+	cfg := DefaultConfig()
+	cfg.Accounts = map[common.Address]*big.Int{crypto.PubkeyToAddress(admin.PublicKey): big.NewInt(1000000000000)}
+	net := NewNetwork(t, cfg)
+	defer net.Stop()
+	defer setAdmin(crypto.PubkeyToAddress(admin.PublicKey))()
+	headerGasLimit := func(n *Node, height int64) uint64 {
+		return n.Backend.Ethereum().BlockChain().GetHeaderByNumber(uint64(height)).GasLimit
+	}
+
+	block := net.NextBlock()
+	oldGasLimit := headerGasLimit(net.Nodes[0], block.Height)
+	newGasLimit := oldGasLimit + 1000000
+
+	block = net.NextBlock(net.SystemTx(admin, emtTypes.SystemTxSetBlockGasLimit,
+		emtTypes.SetBlockGasLimitParams{GasLimit: newGasLimit}))
+	require.Equal(t, abciTypes.CodeTypeOK, block.DeliverTxs[0].Code, block.DeliverTxs[0].Log)
+	// tendermint applies the params of EndBlock from the next height, like the eth state
+	require.NotNil(t, block.EndBlock.ConsensusParamUpdates)
+	require.Equal(t, int64(newGasLimit), block.EndBlock.ConsensusParamUpdates.Block.MaxGas)
+	for _, n := range net.Nodes {
+		require.Equal(t, oldGasLimit, headerGasLimit(n, block.Height))
+	}
+
+	block = net.NextBlock()
+	require.Nil(t, block.EndBlock.ConsensusParamUpdates)
+	for _, n := range net.Nodes {
+		require.Equal(t, newGasLimit, headerGasLimit(n, block.Height))
+		require.Equal(t, newGasLimit, n.Backend.GasLimit())
+	}
+}
+
+func TestNetworkConsensusKeyRotation(t *testing.T) {
+	net := NewNetwork(t, DefaultConfig())
+	defer net.Stop()
+
+	val := net.Validators[0]
+	signer := val.Signer()
+	oldTmAddress := val.TmAddress()
+	rotateTx := func(pubKey tmCrypto.PubKey) *ethTypes.Transaction {
+		return net.SystemTx(val.EthKey, emtTypes.SystemTxRotateConsensusKey,
+			emtTypes.RotateConsensusKeyParams{PubKey: tmTypes.TM2PB.PubKey(pubKey)})
+	}
+	hasUpdate := func(block *Block, tmAddress string, power bool) bool {
+		for _, update := range block.EndBlock.ValidatorUpdates {
+			if fmt.Sprintf("%X", pubKeyAddress(update.PubKey)) == tmAddress && (update.Power > 0) == power {
+				return true
+			}
+		}
+		return false
+	}
+	net.NextEpoch()
+	net.NextBlock()
+
+	// a second rotation in the same epoch replaces the first one
+	firstKey := ed25519.GenPrivKey().PubKey()
+	newKey := ed25519.GenPrivKey().PubKey()
+	newTmAddress := newKey.Address().String()
+	for _, pubKey := range []tmCrypto.PubKey{firstKey, newKey} {
+		block := net.NextBlock(rotateTx(pubKey))
+		require.Equal(t, abciTypes.CodeTypeOK, block.DeliverTxs[0].Code, block.DeliverTxs[0].Log)
+		require.NotEqual(t, int64(0), block.Height%txfilter.EpochBlocks)
+		require.False(t, hasUpdate(block, pubKey.Address().String(), true))
+	}
+	requirePending := func() {
+		for _, n := range net.Nodes {
+			strategy := n.App.GetStrategy()
+			// the current epoch keeps the old key until the boundary
+			curr := strategy.CurrEpochValData.PosTable
+			require.Equal(t, oldTmAddress, curr.PosItemMap[signer].TmAddress)
+			require.Equal(t, val.PubKey(), curr.PosItemMap[signer].PubKey)
+			_, found := curr.TmAddressToSignerMap[newTmAddress]
+			require.False(t, found)
+
+			next := strategy.NextEpochValData.PosTable
+			require.Equal(t, newTmAddress, next.PosItemMap[signer].TmAddress)
+			require.Equal(t, signer, next.TmAddressToSignerMap[newTmAddress])
+			require.Equal(t, signer, next.TmAddressToSignerMap[oldTmAddress])
+			_, found = next.TmAddressToSignerMap[firstKey.Address().String()]
+			require.False(t, found)
+
+			rotation := strategy.ConsensusKeyRotations.Copy().Pending[signer]
+			require.NotNil(t, rotation)
+			require.Equal(t, oldTmAddress, rotation.OldTmAddress)
+			require.Equal(t, newTmAddress, rotation.NewTmAddress)
+		}
+	}
+	requirePending()
+	// the pending rotation and the alias of the old address are read back by a restarted node
+	net.Restart(0)
+	requirePending()
+
+	// the old address leaves the validators at the boundary, the new key comes in
+	block := net.NextEpoch()
+	require.True(t, hasUpdate(block, oldTmAddress, false))
+	require.True(t, hasUpdate(block, newTmAddress, true))
+	requireRetiring := func() {
+		for _, n := range net.Nodes {
+			strategy := n.App.GetStrategy()
+			curr := strategy.CurrEpochValData.PosTable
+			require.Equal(t, newTmAddress, curr.PosItemMap[signer].TmAddress)
+			require.Equal(t, tmTypes.TM2PB.PubKey(newKey), curr.PosItemMap[signer].PubKey)
+			// the old key still signs the next two heights
+			require.Equal(t, signer, curr.TmAddressToSignerMap[oldTmAddress])
+			require.Equal(t, signer, curr.TmAddressToSignerMap[newTmAddress])
+			_, found := strategy.NextEpochValData.PosTable.TmAddressToSignerMap[oldTmAddress]
+			require.False(t, found)
+
+			rotations := strategy.ConsensusKeyRotations.Copy()
+			require.Empty(t, rotations.Pending)
+			require.Len(t, rotations.Retiring, 1)
+			require.Equal(t, block.Height, rotations.Retiring[0].EffectiveHeight)
+		}
+	}
+	requireRetiring()
+	net.Restart(1)
+	requireRetiring()
+
+	// the alias is gone one epoch later
+	net.NextEpoch()
+	for _, n := range net.Nodes {
+		_, found := n.App.GetStrategy().CurrEpochValData.PosTable.TmAddressToSignerMap[oldTmAddress]
+		require.False(t, found)
+	}
+	for _, update := range net.ValidatorSet(net.Height() + 1) {
+		require.NotEqual(t, oldTmAddress, fmt.Sprintf("%X", pubKeyAddress(update.PubKey)))
+	}
+}
+
+func TestNetworkStopBlocks(t *testing.T) {
+	net := NewNetwork(t, DefaultConfig())
+	defer net.Stop()
+
+	net.NextBlock()
+	height := net.Height() + 1
+	stopped := make(chan struct{})
+	// the node is stopped after EndBlock, the stop waits for the end of Commit
+	net.AtCommit(height, func(net *Network, i int) {
+		if i != 0 {
+			return
+		}
+		go func() {
+			net.Nodes[0].App.StopBlocks()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			t.Fatal("the blocks stopped before the commit")
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+	net.NextBlock()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the blocks did not stop after the commit")
+	}
+	n := net.Nodes[0]
+	require.Equal(t, uint64(height), n.Backend.Ethereum().BlockChain().CurrentBlock().NumberU64())
+
+	// the next block does not start
+	began := make(chan struct{})
+	go func() {
+		n.App.BeginBlock(abciTypes.RequestBeginBlock{Header: abciTypes.Header{Height: height + 1}})
+		close(began)
+	}()
+	select {
+	case <-began:
+		t.Fatal("a block began after the stop")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/DTFN/dtfn/version"
+	"github.com/ethereum/go-ethereum/params"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+	tmTypes "github.com/tendermint/tendermint/types"
+)
+
+const consensusParamsKey = "ConsensusParams"
+
+// maxBlockGasLimit keeps the limit representable as tendermint MaxGas (int64)
+const maxBlockGasLimit = uint64(1<<63 - 1)
+
+func validateBlockGasLimit(gasLimit uint64) error {
+	if gasLimit < params.MinGasLimit || gasLimit > maxBlockGasLimit {
+		return fmt.Errorf("block gas limit %v out of range [%v, %v]", gasLimit, params.MinGasLimit, maxBlockGasLimit)
+	}
+	return nil
+}
+
+// initBlockGasLimit sets the block gas limit of a new chain.
+// The version config wins over the gas limit of the eth genesis block.
+func (app *EthermintApplication) initBlockGasLimit(req abciTypes.RequestInitChain) {
+	gasLimit := version.BlockGasLimit
+	if gasLimit == 0 {
+		gasLimit = app.backend.Ethereum().BlockChain().Genesis().GasLimit()
+	}
+	maxBytes := tmTypes.DefaultBlockParams().MaxBytes
+	if req.ConsensusParams != nil && req.ConsensusParams.Block != nil && req.ConsensusParams.Block.MaxBytes > 0 {
+		maxBytes = req.ConsensusParams.Block.MaxBytes
+	}
+	app.strategy.ConsensusParams.BlockMaxBytes = maxBytes
+	app.setBlockGasLimit(gasLimit)
+	app.applyBlockGasLimit()
+	// the work state of block 1 was built before the limit was known
+	if err := app.backend.InitEthState(app.Receiver()); err != nil {
+		panic(fmt.Sprintf("reset work state with block gas limit err %v", err))
+	}
+}
+
+// activateBlockGasLimit moves a running chain from core.CalcGasLimit
+// to the consensus block gas limit of the version config.
+func (app *EthermintApplication) activateBlockGasLimit(height int64) {
+	if app.strategy.ConsensusParams.BlockGasLimit != 0 || version.BlockGasLimit == 0 ||
+		version.BlockGasLimitHeight <= 0 || height < version.BlockGasLimitHeight {
+		return
+	}
+	if app.strategy.ConsensusParams.BlockMaxBytes == 0 {
+		// chains started before the limit was tracked never stored their MaxBytes
+		app.strategy.ConsensusParams.BlockMaxBytes = tmTypes.DefaultBlockParams().MaxBytes
+	}
+	app.setBlockGasLimit(version.BlockGasLimit)
+}
+
+func (app *EthermintApplication) setBlockGasLimit(gasLimit uint64) {
+	app.strategy.ConsensusParams.BlockGasLimit = gasLimit
+	app.strategy.ConsensusParams.ChangedFlagThisBlock = true
+}
+
+// applyBlockGasLimit hands the block gas limit to the eth state, the next work state uses it.
+func (app *EthermintApplication) applyBlockGasLimit() {
+	app.backend.Es().SetBlockGasLimit(app.strategy.ConsensusParams.BlockGasLimit)
+}
+
+// consensusParamUpdates returns the tendermint params matching the block gas limit
+func (app *EthermintApplication) consensusParamUpdates() *abciTypes.ConsensusParams {
+	return &abciTypes.ConsensusParams{
+		Block: &abciTypes.BlockParams{
+			MaxBytes: app.strategy.ConsensusParams.BlockMaxBytes,
+			MaxGas:   int64(app.strategy.ConsensusParams.BlockGasLimit),
+		},
+	}
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+	tmlibs "github.com/tendermint/tendermint/libs/common"
+
+	"github.com/DTFN/dtfn/ethereum"
+	"github.com/DTFN/dtfn/version"
+)
+
+// SetMinGasPrice sets the minimum gas price this node accepts in CheckTx
+func (app *EthermintApplication) SetMinGasPrice(price *big.Int) {
+	app.minGasPrice = price
+}
+
+// checkLocalGasPrice checks the tx against the min gas price of this node.
+// It is a mempool policy only and must never be used in DeliverTx.
+func (app *EthermintApplication) checkLocalGasPrice(tx *ethTypes.Transaction) error {
+	if app.minGasPrice != nil && tx.GasPrice().Cmp(app.minGasPrice) < 0 {
+		return fmt.Errorf("gas price %v below node minimum %v", tx.GasPrice(), app.minGasPrice)
+	}
+	return nil
+}
+
+// checkConsensusGasPrice checks the tx against the consensus min gas price
+// and the base fee of the block at height.
+func (app *EthermintApplication) checkConsensusGasPrice(tx *ethTypes.Transaction, height int64) error {
+	if version.MinGasPriceHeight > 0 && height >= version.MinGasPriceHeight &&
+		tx.GasPrice().Cmp(version.MinGasPrice) < 0 {
+		return fmt.Errorf("gas price %v below consensus minimum %v", tx.GasPrice(), version.MinGasPrice)
+	}
+	if baseFee := app.backend.BaseFee(); baseFee != nil && tx.GasPrice().Cmp(baseFee) < 0 {
+		return fmt.Errorf("gas price %v below base fee %v", tx.GasPrice(), baseFee)
+	}
+	return nil
+}
+
+// priorityEvents reports the priority of a checked tx to tendermint.
+func (app *EthermintApplication) priorityEvents(tx *ethTypes.Transaction) []abciTypes.Event {
+	priority := ethereum.TxPriority(tx, app.backend.BaseFee())
+	return []abciTypes.Event{{
+		Type: "tx",
+		Attributes: []tmlibs.KVPair{
+			{Key: []byte("priority"), Value: []byte(strconv.FormatInt(priority, 10))},
+		},
+	}}
+}
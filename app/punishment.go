@@ -10,6 +10,8 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"fmt"
 	"encoding/hex"
+	"strconv"
+	tmlibs "github.com/tendermint/tendermint/libs/common"
 )
 
 type Punishment struct {
@@ -22,11 +24,11 @@ func NewPunishment(as AmountStrategy, ss SubBalanceStrategy) *Punishment {
 	return punishment
 }
 
-func (p *Punishment) Punish(stateDB *state.StateDB, byzantine common.Address) error {
+// Punish takes the punishment amount from byzantine and returns what was actually taken
+func (p *Punishment) Punish(stateDB *state.StateDB, byzantine common.Address, coinbase common.Address) *big.Int {
 	as := p.AmountStrategy
 	ss := p.SubBalanceStrategy
-	ss.subBalance(stateDB, byzantine, as.amount(stateDB, byzantine))
-	return nil
+	return ss.subBalance(stateDB, byzantine, as.amount(stateDB, byzantine), coinbase)
 }
 
 type AmountStrategy interface {
@@ -49,25 +51,36 @@ func (f Percent100AmountStrategy) amount(stateDB *state.StateDB, byzantine commo
 }
 
 type SubBalanceStrategy interface {
-	subBalance(stateDB *state.StateDB, byzantine common.Address, balance *big.Int)
+	subBalance(stateDB *state.StateDB, byzantine common.Address, balance *big.Int, coinbase common.Address) *big.Int
+	// destination returns who receives the amount, nil if it is burned
+	destination(coinbase common.Address) *common.Address
 }
 
 type BurnStrategy struct {
 }
 
-func (s BurnStrategy) subBalance(stateDB *state.StateDB, byzantine common.Address, balance *big.Int) {
-	subBalance(stateDB, byzantine, balance)
+func (s BurnStrategy) subBalance(stateDB *state.StateDB, byzantine common.Address, balance *big.Int, coinbase common.Address) *big.Int {
+	return subBalance(stateDB, byzantine, balance)
+}
+
+func (s BurnStrategy) destination(coinbase common.Address) *common.Address {
+	return nil
 }
 
+// TransferStrategy gives the amount to the coinbase of the block
 type TransferStrategy struct {
-	transferTo common.Address
 }
 
-func (s TransferStrategy) subBalance(stateDB *state.StateDB, addr common.Address, amount *big.Int) {
+func (s TransferStrategy) subBalance(stateDB *state.StateDB, addr common.Address, amount *big.Int, coinbase common.Address) *big.Int {
 	amount = subBalance(stateDB, addr, amount)
 	if amount.Cmp(big.NewInt(0)) > 0 {
-		stateDB.AddBalance(s.transferTo, amount)
+		stateDB.AddBalance(coinbase, amount)
 	}
+	return amount
+}
+
+func (s TransferStrategy) destination(coinbase common.Address) *common.Address {
+	return &coinbase
 }
 
 func subBalance(stateDB *state.StateDB, addr common.Address, amount *big.Int) *big.Int {
@@ -83,15 +96,26 @@ func subBalance(stateDB *state.StateDB, addr common.Address, amount *big.Int) *b
 	return amount
 }
 
-func (p *Punishment) DoPunish(stateDB *state.StateDB, strategy *types.Strategy, evidences []abciTypes.Evidence, coinbase common.Address, currentHeight int64) {
-	if transferStrategy, ok := p.SubBalanceStrategy.(TransferStrategy); ok {
-		transferStrategy.transferTo = coinbase
-	}
-	for _, e := range evidences {
-		signer, found := strategy.NextEpochValData.PosTable.TmAddressToSignerMap[strings.ToUpper(hex.EncodeToString(e.Validator.Address))]
+// DoPunish slashes the signers of the evidences and returns a journal entry for each of them
+func (p *Punishment) DoPunish(stateDB *state.StateDB, strategy *types.Strategy, evidences []abciTypes.Evidence, coinbase common.Address, currentHeight int64) []*types.SlashRecord {
+	records := make([]*types.SlashRecord, 0)
+	for i, e := range evidences {
+		tmAddress := strings.ToUpper(hex.EncodeToString(e.Validator.Address))
+		signer, found := strategy.NextEpochValData.PosTable.TmAddressToSignerMap[tmAddress]
 		if found {
-			p.Punish(stateDB, signer)
+			amount := p.Punish(stateDB, signer, coinbase)
 			log.Info(fmt.Sprintf("evil signer %v got slashed because of Evidence %v", signer, e))
+			record := &types.SlashRecord{
+				Height:         currentHeight,
+				Index:          i,
+				EvidenceType:   e.Type,
+				EvidenceHeight: e.Height,
+				TmAddress:      tmAddress,
+				Signer:         signer,
+				Amount:         amount,
+				Destination:    p.SubBalanceStrategy.destination(coinbase),
+				PosTableAction: types.SlashActionAlreadyUnbonded,
+			}
 			_, found := strategy.NextEpochValData.PosTable.PosItemMap[signer]
 			if found { //evil signer has not unbonded, kicked it out
 				err := strategy.NextEpochValData.PosTable.RemovePosItem(signer, currentHeight, true)
@@ -101,6 +125,7 @@ func (p *Punishment) DoPunish(stateDB *state.StateDB, strategy *types.Strategy,
 						panic(fmt.Sprintf("evil signer %v cannot be found in either posItemMap or unbondedPosItemMap of NextEpochValData.PosTable. but is in the TmAddressToSignerMap", signer))
 					}
 				} else {
+					record.PosTableAction = types.SlashActionRemoved
 					log.Info(fmt.Sprintf("evil signer %v got unbonded because of Evidence %v", signer, e))
 				}
 			} else { //he should be in the unbonded map
@@ -109,8 +134,34 @@ func (p *Punishment) DoPunish(stateDB *state.StateDB, strategy *types.Strategy,
 					panic(fmt.Sprintf("evil signer %v cannot be found in either posItemMap or unbondedPosItemMap of CurrEpochValData.PosTable. but is in the TmAddressToSignerMap", signer))
 				}
 			}
+			records = append(records, record)
 		} else {
 			log.Error(fmt.Sprintf("Fail to punish address %X. Evidence %v is too long ago?", e.Validator.Address, e))
 		}
 	}
+	return records
+}
+
+// slashEvents reports each slash of the block as an abci event
+func slashEvents(records []*types.SlashRecord) []abciTypes.Event {
+	events := make([]abciTypes.Event, 0, len(records))
+	for _, record := range records {
+		destination := "burned"
+		if record.Destination != nil {
+			destination = strings.ToLower(record.Destination.Hex())
+		}
+		events = append(events, abciTypes.Event{
+			Type: "slash",
+			Attributes: []tmlibs.KVPair{
+				{Key: []byte("evidence"), Value: []byte(record.EvidenceType)},
+				{Key: []byte("evidenceHeight"), Value: []byte(strconv.FormatInt(record.EvidenceHeight, 10))},
+				{Key: []byte("validator"), Value: []byte(record.TmAddress)},
+				{Key: []byte("signer"), Value: []byte(strings.ToLower(record.Signer.Hex()))},
+				{Key: []byte("amount"), Value: []byte(record.Amount.String())},
+				{Key: []byte("destination"), Value: []byte(destination)},
+				{Key: []byte("posTableAction"), Value: []byte(record.PosTableAction)},
+			},
+		})
+	}
+	return events
 }
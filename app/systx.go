@@ -0,0 +1,133 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	emtTypes "github.com/DTFN/dtfn/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txfilter"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+	tmlibs "github.com/tendermint/tendermint/libs/common"
+)
+
+var errNotAdmin = errors.New("system tx sender is not the chain admin")
+
+type systemTxHandler struct {
+	// check validates the authorization and the params, it must not change any state
+	check func(app *EthermintApplication, from common.Address, params json.RawMessage) error
+	// deliver applies the tx, it is only called after check passed in the same DeliverTx
+	deliver func(app *EthermintApplication, from common.Address, params json.RawMessage) error
+}
+
+var systemTxHandlers = map[string]systemTxHandler{
+	emtTypes.SystemTxSetBlockGasLimit:    {checkSetBlockGasLimit, deliverSetBlockGasLimit},
+	emtTypes.SystemTxSetDeployRestricted: {checkSetDeployRestricted, deliverSetDeployRestricted},
+	emtTypes.SystemTxAddDeployer:         {checkAccountParams, deliverAddDeployer},
+	emtTypes.SystemTxRemoveDeployer:      {checkAccountParams, deliverRemoveDeployer},
+	emtTypes.SystemTxSetContractCallers:  {checkSetContractCallers, deliverSetContractCallers},
+	emtTypes.SystemTxSetValueLimit:       {checkSetValueLimit, deliverSetValueLimit},
+	emtTypes.SystemTxSetAdminSet:         {checkSetAdminSet, deliverSetAdminSet},
+	emtTypes.SystemTxApproveTx:           {checkApproveTx, deliverApproveTx},
+	emtTypes.SystemTxRotateAdmin:         {checkRotateAdmin, deliverRotateAdmin},
+}
+
+// validatorTxHandlers handle the system txs signed by a validator for itself, they need no admin approval
+var validatorTxHandlers = map[string]systemTxHandler{
+	emtTypes.SystemTxUpdateBeneficiary:  {checkUpdateBeneficiary, deliverUpdateBeneficiary},
+	emtTypes.SystemTxRotateBlsKey:       {checkRotateBlsKey, deliverRotateBlsKey},
+	emtTypes.SystemTxRotateConsensusKey: {checkRotateConsensusKey, deliverRotateConsensusKey},
+}
+
+// checkSystemTx checks the authorization and the params of a system tx.
+// It is run in CheckTx and again in DeliverTx before the tx is executed.
+func (app *EthermintApplication) checkSystemTx(from common.Address, tx *ethTypes.Transaction) error {
+	systemTx, err := emtTypes.DecodeSystemTx(tx.Data())
+	if err != nil {
+		return fmt.Errorf("invalid system tx: %v", err)
+	}
+	switch systemTx.Method {
+	case emtTypes.SystemTxPropose:
+		return app.checkPropose(from, systemTx.Params)
+	case emtTypes.SystemTxApprove:
+		return app.checkApprove(from, systemTx.Params)
+	}
+	if handler, ok := validatorTxHandlers[systemTx.Method]; ok {
+		if err := handler.check(app, from, systemTx.Params); err != nil {
+			return fmt.Errorf("invalid %s: %v", systemTx.Method, err)
+		}
+		return nil
+	}
+	handler, ok := systemTxHandlers[systemTx.Method]
+	if !ok {
+		return fmt.Errorf("unknown system tx method %q", systemTx.Method)
+	}
+	if app.strategy.AdminSet.Enabled() {
+		return fmt.Errorf("%s needs the approval of the admin set, submit it with %s",
+			systemTx.Method, emtTypes.SystemTxPropose)
+	}
+	if from != txfilter.PPChainAdmin {
+		return errNotAdmin
+	}
+	if err := handler.check(app, from, systemTx.Params); err != nil {
+		return fmt.Errorf("invalid %s params: %v", systemTx.Method, err)
+	}
+	return nil
+}
+
+// deliverSystemTx applies a system tx after it was executed successfully.
+// The tx has passed checkSystemTx in the same DeliverTx, errors are not expected here.
+func (app *EthermintApplication) deliverSystemTx(from common.Address, tx *ethTypes.Transaction) ([]abciTypes.Event, error) {
+	systemTx, err := emtTypes.DecodeSystemTx(tx.Data())
+	if err != nil {
+		return nil, err
+	}
+	attributes := []tmlibs.KVPair{
+		{Key: []byte("method"), Value: []byte(systemTx.Method)},
+		{Key: []byte("from"), Value: []byte(strings.ToLower(from.Hex()))},
+	}
+	var proposalAttributes []tmlibs.KVPair
+	switch systemTx.Method {
+	case emtTypes.SystemTxPropose:
+		proposalAttributes, err = app.deliverPropose(from, systemTx.Params)
+	case emtTypes.SystemTxApprove:
+		proposalAttributes, err = app.deliverApprove(from, systemTx.Params)
+	default:
+		handler, ok := validatorTxHandlers[systemTx.Method]
+		if !ok {
+			handler, ok = systemTxHandlers[systemTx.Method]
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown system tx method %q", systemTx.Method)
+		}
+		err = handler.deliver(app, from, systemTx.Params)
+	}
+	if err != nil {
+		return nil, err
+	}
+	app.logger.Info("system tx applied", "method", systemTx.Method, "from", from.Hex(), "params", string(systemTx.Params))
+	return []abciTypes.Event{{
+		Type:       "systx",
+		Attributes: append(attributes, proposalAttributes...),
+	}}, nil
+}
+
+func checkSetBlockGasLimit(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.SetBlockGasLimitParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	return validateBlockGasLimit(p.GasLimit)
+}
+
+func deliverSetBlockGasLimit(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.SetBlockGasLimitParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	app.setBlockGasLimit(p.GasLimit)
+	return nil
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+
+	emtTypes "github.com/DTFN/dtfn/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const txPolicyKey = "TxPolicy"
+
+func checkSetDeployRestricted(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.SetDeployRestrictedParams{}
+	return json.Unmarshal(params, &p)
+}
+
+func deliverSetDeployRestricted(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.SetDeployRestrictedParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	app.strategy.TxPolicy.SetDeployRestricted(p.Restricted)
+	return nil
+}
+
+func checkAccountParams(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.AccountParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	if p.Account == (common.Address{}) {
+		return errors.New("empty account")
+	}
+	return nil
+}
+
+func deliverAddDeployer(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.AccountParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	app.strategy.TxPolicy.SetDeployer(p.Account, true)
+	return nil
+}
+
+func deliverRemoveDeployer(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.AccountParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	app.strategy.TxPolicy.SetDeployer(p.Account, false)
+	return nil
+}
+
+func checkSetContractCallers(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.SetContractCallersParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	if p.Contract == (common.Address{}) {
+		return errors.New("empty contract")
+	}
+	return nil
+}
+
+func deliverSetContractCallers(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.SetContractCallersParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	app.strategy.TxPolicy.SetContractCallers(p.Contract, p.Callers)
+	return nil
+}
+
+func checkSetValueLimit(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.SetValueLimitParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	if p.Account == (common.Address{}) {
+		return errors.New("empty account")
+	}
+	if p.Limit != nil && p.Limit.Sign() < 0 {
+		return errors.New("negative limit")
+	}
+	return nil
+}
+
+func deliverSetValueLimit(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.SetValueLimitParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	app.strategy.TxPolicy.SetValueLimit(p.Account, p.Limit)
+	return nil
+}
@@ -2,11 +2,14 @@ package app
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	emtTypes "github.com/DTFN/dtfn/types"
 	"github.com/DTFN/dtfn/version"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/txfilter"
 	"github.com/ethereum/go-ethereum/core/types"
 	ethereumCrypto "github.com/ethereum/go-ethereum/crypto"
@@ -45,11 +48,27 @@ func (app *EthermintApplication) Receiver() common.Address {
 	return common.Address{}
 }
 
+// SetHttpServerAddr sets the listen address of the http server, it must be called before StartHttpServer
+func (app *EthermintApplication) SetHttpServerAddr(addr string) {
+	app.httpServer.HttpServer.Addr = addr
+}
+
 func (app *EthermintApplication) StartHttpServer() {
 	go app.httpServer.HttpServer.ListenAndServe()
 	//go http.ListenAndServe("0.0.0.0:6060", nil)
 }
 
+// StopHttpServer stops the http server, it waits for the running requests until ctx is done
+func (app *EthermintApplication) StopHttpServer(ctx context.Context) error {
+	return app.httpServer.HttpServer.Shutdown(ctx)
+}
+
+// StopBlocks waits for the block in progress, from BeginBlock to the end of its Commit, and keeps
+// the next ones from starting, the chain db can be closed once it returns
+func (app *EthermintApplication) StopBlocks() {
+	app.blockMtx.Lock()
+}
+
 // GetUpdatedValidators returns an updated validator set from the strategy
 // #unstable
 func (app *EthermintApplication) GetUpdatedValidators(height int64, seed []byte) abciTypes.ResponseEndBlock {
@@ -120,6 +139,7 @@ func (app *EthermintApplication) InitPersistData() bool {
 		}
 	}
 	txfilter.AppVersion = app.strategy.HFExpectedData.BlockVersion
+	// the version config is the default, an admin rotated on chain is applied after reading AdminRegistry
 	if txfilter.AppVersion <= 4 {
 		txfilter.PPChainAdmin = common.HexToAddress(version.PPChainAdmin)
 	} else {
@@ -148,6 +168,42 @@ func (app *EthermintApplication) InitPersistData() bool {
 	app.logger.Info("Read AuthTable")
 	app.strategy.AuthTable = wsState.InitAuthTable()
 
+	app.logger.Info("Read TxPolicy")
+	if _, err := loadTrieData(wsState, txfilter.SendToAuth, txPolicyKey, app.strategy.TxPolicy); err != nil {
+		panic(fmt.Sprintf("initialize TxPolicy error %v", err))
+	}
+	app.strategy.TxPolicy.InitStruct()
+
+	app.logger.Info("Read AdminSet")
+	if _, err := loadTrieData(wsState, emtTypes.SystemTxAddress, adminSetKey, app.strategy.AdminSet); err != nil {
+		panic(fmt.Sprintf("initialize AdminSet error %v", err))
+	}
+	app.strategy.AdminSet.InitStruct()
+
+	app.logger.Info("Read ConsensusKeyRotations")
+	if _, err := loadTrieData(wsState, emtTypes.SystemTxAddress, consensusKeyRotationsKey, app.strategy.ConsensusKeyRotations); err != nil {
+		panic(fmt.Sprintf("initialize ConsensusKeyRotations error %v", err))
+	}
+	app.strategy.ConsensusKeyRotations.InitStruct()
+	app.strategy.ConsensusKeyRotations.RestoreAliases(app.strategy.CurrEpochValData.PosTable, app.strategy.NextEpochValData.PosTable)
+
+	app.logger.Info("Read AdminRegistry")
+	if _, err := loadTrieData(wsState, emtTypes.SystemTxAddress, adminRegistryKey, app.strategy.AdminRegistry); err != nil {
+		panic(fmt.Sprintf("initialize AdminRegistry error %v", err))
+	}
+	app.applyAdminRegistry()
+
+	app.logger.Info("Read ConsensusParams")
+	if _, err := loadTrieData(wsState, emtTypes.SystemTxAddress, consensusParamsKey, &app.strategy.ConsensusParams); err != nil {
+		panic(fmt.Sprintf("initialize ConsensusParams error %v", err))
+	}
+	if app.strategy.ConsensusParams.BlockGasLimit != 0 {
+		app.applyBlockGasLimit()
+		if err := app.backend.InitEthState(app.Receiver()); err != nil {
+			panic(fmt.Sprintf("reset work state with block gas limit err %v", err))
+		}
+	}
+
 	return true
 }
 
@@ -198,6 +254,31 @@ func (app *EthermintApplication) SetPersistenceData() {
 		wsState.SetCode(currEpochDataAddress, currBytes)
 	}
 
+	if app.strategy.TxPolicy.ChangedFlagThisBlock {
+		persistTrieData(wsState, txfilter.SendToAuth, txPolicyKey, app.strategy.TxPolicy)
+		app.strategy.TxPolicy.ChangedFlagThisBlock = false
+	}
+
+	if app.strategy.AdminSet.ChangedFlagThisBlock {
+		persistTrieData(wsState, emtTypes.SystemTxAddress, adminSetKey, app.strategy.AdminSet)
+		app.strategy.AdminSet.ChangedFlagThisBlock = false
+	}
+
+	if app.strategy.ConsensusKeyRotations.ChangedFlagThisBlock {
+		persistTrieData(wsState, emtTypes.SystemTxAddress, consensusKeyRotationsKey, app.strategy.ConsensusKeyRotations)
+		app.strategy.ConsensusKeyRotations.ChangedFlagThisBlock = false
+	}
+
+	if app.strategy.AdminRegistry.ChangedFlagThisBlock {
+		persistTrieData(wsState, emtTypes.SystemTxAddress, adminRegistryKey, app.strategy.AdminRegistry)
+		app.strategy.AdminRegistry.ChangedFlagThisBlock = false
+	}
+
+	if app.strategy.ConsensusParams.ChangedFlagThisBlock {
+		persistTrieData(wsState, emtTypes.SystemTxAddress, consensusParamsKey, app.strategy.ConsensusParams)
+		app.strategy.ConsensusParams.ChangedFlagThisBlock = false
+	}
+
 	trie := wsState.GetOrNewStateObject(currEpochDataAddress).GetTrie(wsState.Database())
 	key := []byte("CurrentHeightData")
 	keyHash := common.BytesToHash(key)
@@ -208,3 +289,30 @@ func (app *EthermintApplication) SetPersistenceData() {
 	wsState.SetState(currEpochDataAddress, keyHash, valueHash)
 	app.logger.Debug(fmt.Sprintf("CurrentHeightValData %v", app.strategy.CurrentHeightValData))
 }
+
+// persistTrieData stores value json encoded under key in the storage trie of address
+func persistTrieData(wsState *state.StateDB, address common.Address, key string, value interface{}) {
+	trie := wsState.GetOrNewStateObject(address).GetTrie(wsState.Database())
+	keyBytes := []byte(key)
+	valBytes, _ := json.Marshal(value)
+	trie.TryUpdate(keyBytes, valBytes)
+	valueHash := ethereumCrypto.Keccak256Hash(valBytes)
+	wsState.SetState(address, common.BytesToHash(keyBytes), valueHash)
+}
+
+// loadTrieData reads a value stored by persistTrieData, it returns false if nothing was stored
+func loadTrieData(wsState *state.StateDB, address common.Address, key string, value interface{}) (bool, error) {
+	keyBytes := []byte(key)
+	valueHash := wsState.GetState(address, common.BytesToHash(keyBytes))
+	if bytes.Equal(valueHash.Bytes(), common.Hash{}.Bytes()) {
+		return false, nil
+	}
+	valBytes, err := wsState.StorageTrie(address).TryGet(keyBytes)
+	if err != nil {
+		return false, err
+	}
+	if len(valBytes) == 0 {
+		return false, fmt.Errorf("%s of %v has a hash but no data", key, address.Hex())
+	}
+	return true, json.Unmarshal(valBytes, value)
+}
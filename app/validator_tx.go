@@ -0,0 +1,160 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	emtTypes "github.com/DTFN/dtfn/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txfilter"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+	tmTypes "github.com/tendermint/tendermint/types"
+)
+
+const consensusKeyRotationsKey = "ConsensusKeyRotations"
+
+// The validator txs replace the PosItem of the sender in NextEpochValData.PosTable with a changed copy,
+// CurrEpochValData may share the item until the next epoch boundary.
+// A rotated consensus key is then replaced by blsValidators like any other validator change.
+
+// bondedPosItem returns the PosItem of a bonded signer in the next epoch PosTable
+func (app *EthermintApplication) bondedPosItem(signer common.Address) (*txfilter.PosItem, error) {
+	posItem, ok := app.strategy.NextEpochValData.PosTable.PosItemMap[signer]
+	if !ok {
+		return nil, fmt.Errorf("signer %X is not bonded", signer)
+	}
+	return posItem, nil
+}
+
+// replacePosItem sets the PosItem of a bonded signer in the next epoch PosTable
+func (app *EthermintApplication) replacePosItem(signer common.Address, posItem *txfilter.PosItem) {
+	app.strategy.NextEpochValData.PosTable.PosItemMap[signer] = posItem
+	app.strategy.NextEpochValData.PosTable.ChangedFlagThisBlock = true
+}
+
+// parseBlsKey decodes a bls public key and returns its encoding, as in the initial account map
+func parseBlsKey(blsKeyString string) (string, error) {
+	var blsKey tmTypes.BLSPubKey
+	if err := json.Unmarshal([]byte(blsKeyString), &blsKey); err != nil {
+		return "", fmt.Errorf("invalid bls key string: %v", err)
+	}
+	if blsKey.Type == "" || blsKey.Address == "" {
+		return "", errors.New("invalid bls key string: missing type or address")
+	}
+	encoded, err := json.Marshal(blsKey)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+func checkUpdateBeneficiary(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.UpdateBeneficiaryParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	if p.Beneficiary == (common.Address{}) {
+		return errors.New("empty beneficiary")
+	}
+	_, err := app.bondedPosItem(from)
+	return err
+}
+
+func deliverUpdateBeneficiary(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.UpdateBeneficiaryParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	posItem, err := app.bondedPosItem(from)
+	if err != nil {
+		return err
+	}
+	updated := *posItem
+	updated.Beneficiary = p.Beneficiary
+	app.replacePosItem(from, &updated)
+	return nil
+}
+
+func checkRotateBlsKey(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.RotateBlsKeyParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	blsKeyString, err := parseBlsKey(p.BlsKeyString)
+	if err != nil {
+		return err
+	}
+	if _, err := app.bondedPosItem(from); err != nil {
+		return err
+	}
+	// a bls key signs for one validator only
+	for signer, posItem := range app.strategy.NextEpochValData.PosTable.PosItemMap {
+		if posItem.BlsKeyString == blsKeyString && signer != from {
+			return fmt.Errorf("bls key already used by %X", signer)
+		}
+	}
+	return nil
+}
+
+func deliverRotateBlsKey(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	p := emtTypes.RotateBlsKeyParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	posItem, err := app.bondedPosItem(from)
+	if err != nil {
+		return err
+	}
+	blsKeyString, err := parseBlsKey(p.BlsKeyString)
+	if err != nil {
+		return err
+	}
+	updated := *posItem
+	updated.BlsKeyString = blsKeyString
+	app.replacePosItem(from, &updated)
+	return nil
+}
+
+// newConsensusKey checks the new tendermint key of a rotation and returns its tm address
+func (app *EthermintApplication) newConsensusKey(params json.RawMessage) (abciTypes.PubKey, string, error) {
+	p := emtTypes.RotateConsensusKeyParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return p.PubKey, "", err
+	}
+	tmPubKey, err := tmTypes.PB2TM.PubKey(p.PubKey)
+	if err != nil {
+		return p.PubKey, "", err
+	}
+	tmAddress := tmPubKey.Address().String()
+	if _, ok := app.strategy.NextEpochValData.PosTable.TmAddressToSignerMap[tmAddress]; ok {
+		return p.PubKey, "", fmt.Errorf("tm address %v already used", tmAddress)
+	}
+	if _, ok := app.strategy.CurrEpochValData.PosTable.TmAddressToSignerMap[tmAddress]; ok {
+		return p.PubKey, "", fmt.Errorf("tm address %v already used", tmAddress)
+	}
+	return p.PubKey, tmAddress, nil
+}
+
+func checkRotateConsensusKey(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	if _, err := app.bondedPosItem(from); err != nil {
+		return err
+	}
+	_, _, err := app.newConsensusKey(params)
+	return err
+}
+
+func deliverRotateConsensusKey(app *EthermintApplication, from common.Address, params json.RawMessage) error {
+	posItem, err := app.bondedPosItem(from)
+	if err != nil {
+		return err
+	}
+	pubKey, tmAddress, err := app.newConsensusKey(params)
+	if err != nil {
+		return err
+	}
+	updated := app.strategy.ConsensusKeyRotations.Schedule(app.strategy.NextEpochValData.PosTable, from, posItem,
+		pubKey, tmAddress, app.strategy.HFExpectedData.Height)
+	app.replacePosItem(from, updated)
+	return nil
+}
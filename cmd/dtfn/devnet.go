@@ -0,0 +1,461 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/tendermint/tendermint/cmd/tendermint/commands"
+	cfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/consensus"
+	"github.com/tendermint/tendermint/p2p"
+	"gopkg.in/urfave/cli.v1"
+)
+
+const (
+	devnetStateFileName = "devnet.json"
+	devnetLogFileName   = "dtfn.log"
+	// every node uses devnetPortsPerNode consecutive ports from --base_port
+	devnetPortsPerNode = 10
+	devnetStopTimeout  = 10 * time.Second
+)
+
+var (
+	devnetDirFlag = cli.StringFlag{
+		Name:  "devnet_dir",
+		Value: "./devnet",
+		Usage: "directory holding the configs, the data and the logs of the devnet nodes",
+	}
+	devnetBasePortFlag = cli.IntFlag{
+		Name:  "base_port",
+		Value: 36000,
+		Usage: "first port of the devnet, node i uses the ports base_port+10*i to base_port+10*i+5",
+	}
+	devnetDetachFlag = cli.BoolFlag{
+		Name:  "detach",
+		Usage: "return once the nodes are started instead of tailing their logs",
+	}
+
+	devnetCommand = cli.Command{
+		Name:  "devnet",
+		Usage: "run a local multi-validator network",
+		Subcommands: []cli.Command{
+			{
+				Action:    devnetUpCmd,
+				Name:      "up",
+				Usage:     "generate the devnet if needed, start its nodes and tail their logs",
+				ArgsUsage: "<N>",
+				Flags:     []cli.Flag{devnetDirFlag, devnetBasePortFlag, devnetDetachFlag},
+			},
+			{
+				Action: devnetDownCmd,
+				Name:   "down",
+				Usage:  "stop the devnet nodes, keeping their data",
+				Flags:  []cli.Flag{devnetDirFlag},
+			},
+			{
+				Action: devnetResetCmd,
+				Name:   "reset",
+				Usage:  "stop the devnet nodes and remove all their data",
+				Flags:  []cli.Flag{devnetDirFlag},
+			},
+			{
+				Action: devnetLogsCmd,
+				Name:   "logs",
+				Usage:  "tail the combined logs of the devnet nodes",
+				Flags:  []cli.Flag{devnetDirFlag},
+			},
+		},
+	}
+)
+
+type devnetPorts struct {
+	P2P    int `json:"p2p"`
+	TmRPC  int `json:"tm_rpc"`
+	ABCI   int `json:"abci"`
+	EthRPC int `json:"eth_rpc"`
+	WS     int `json:"ws"`
+	HTTP   int `json:"http"`
+}
+
+func newDevnetPorts(basePort int, index int) devnetPorts {
+	first := basePort + devnetPortsPerNode*index
+	return devnetPorts{
+		P2P:    first,
+		TmRPC:  first + 1,
+		ABCI:   first + 2,
+		EthRPC: first + 3,
+		WS:     first + 4,
+		HTTP:   first + 5,
+	}
+}
+
+type devnetNode struct {
+	Name    string      `json:"name"`
+	DataDir string      `json:"data_dir"`
+	NodeID  string      `json:"node_id"`
+	Ports   devnetPorts `json:"ports"`
+	Pid     int         `json:"pid,omitempty"`
+}
+
+func (node *devnetNode) logFile() string {
+	return filepath.Join(node.DataDir, devnetLogFileName)
+}
+
+// devnetState is saved in devnet.json, it is what down and reset need to find the nodes
+type devnetState struct {
+	Nodes []*devnetNode `json:"nodes"`
+}
+
+func loadDevnetState(dir string) (*devnetState, error) {
+	stateJSON, err := ioutil.ReadFile(filepath.Join(dir, devnetStateFileName))
+	if err != nil {
+		return nil, err
+	}
+	state := &devnetState{}
+	if err := json.Unmarshal(stateJSON, state); err != nil {
+		return nil, fmt.Errorf("invalid devnet state %v: %v", filepath.Join(dir, devnetStateFileName), err)
+	}
+	return state, nil
+}
+
+func (state *devnetState) save(dir string) error {
+	stateJSON, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, devnetStateFileName), stateJSON, 0644)
+}
+
+func devnetUpCmd(ctx *cli.Context) error {
+	dir := ctx.String(devnetDirFlag.Name)
+	state, err := loadDevnetState(dir)
+	if os.IsNotExist(err) {
+		n, err := strconv.Atoi(ctx.Args().First())
+		if err != nil || n <= 0 {
+			return fmt.Errorf("usage: %v devnet up <N>, N being the number of validators", app.Name)
+		}
+		if state, err = generateDevnet(dir, n, ctx.Int(devnetBasePortFlag.Name)); err != nil {
+			return err
+		}
+		fmt.Printf("Generated a devnet of %v validators in %v\n", n, dir)
+	} else if err != nil {
+		return err
+	} else if ctx.NArg() != 0 && ctx.Args().First() != strconv.Itoa(len(state.Nodes)) {
+		return fmt.Errorf("%v already holds a devnet of %v nodes, reset it to change its size", dir, len(state.Nodes))
+	}
+
+	if err := startDevnet(state); err != nil {
+		// do not leave half of the devnet running
+		stopDevnet(state)
+		state.save(dir)
+		return err
+	}
+	if err := state.save(dir); err != nil {
+		return err
+	}
+	for _, node := range state.Nodes {
+		fmt.Printf("%v pid %v: eth rpc http://127.0.0.1:%v, tendermint rpc tcp://127.0.0.1:%v, http http://127.0.0.1:%v\n",
+			node.Name, node.Pid, node.Ports.EthRPC, node.Ports.TmRPC, node.Ports.HTTP)
+	}
+	if ctx.Bool(devnetDetachFlag.Name) {
+		return nil
+	}
+	fmt.Printf("Tailing the logs, ctrl-c leaves the nodes running. Stop them with: %v devnet down --%v %v\n",
+		app.Name, devnetDirFlag.Name, dir)
+	return tailDevnetLogs(state)
+}
+
+func devnetDownCmd(ctx *cli.Context) error {
+	dir := ctx.String(devnetDirFlag.Name)
+	state, err := loadDevnetState(dir)
+	if err != nil {
+		return err
+	}
+	stopDevnet(state)
+	return state.save(dir)
+}
+
+func devnetResetCmd(ctx *cli.Context) error {
+	dir := ctx.String(devnetDirFlag.Name)
+	state, err := loadDevnetState(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if state != nil {
+		stopDevnet(state)
+	}
+	fmt.Printf("Removing %v\n", dir)
+	return os.RemoveAll(dir)
+}
+
+func devnetLogsCmd(ctx *cli.Context) error {
+	state, err := loadDevnetState(ctx.String(devnetDirFlag.Name))
+	if err != nil {
+		return err
+	}
+	return tailDevnetLogs(state)
+}
+
+// generateDevnet writes the keys, the genesis files and the initial accounts of n validators,
+// each node living in its own dtfn datadir with its own ports
+func generateDevnet(dir string, n int, basePort int) (*devnetState, error) {
+	if err := os.MkdirAll(dir, nodeDirPerm); err != nil {
+		return nil, err
+	}
+	state := &devnetState{}
+	spec := &GenesisSpec{
+		ChainID:     "dtfn-devnet",
+		GenesisTime: time.Now().UTC().Truncate(time.Second).Format(time.RFC3339),
+		Eth: EthGenesisSpec{
+			ChainID:    15,
+			Difficulty: "0x40",
+		},
+	}
+	// every validator gets the same stake, far above the PosTable threshold
+	balance := new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("node%d", i)
+		dataDir, err := filepath.Abs(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		tmHome := filepath.Join(dataDir, "tendermint")
+		config := cfg.DefaultConfig()
+		config.SetRoot(tmHome)
+		for _, sub := range []string{"config", "data"} {
+			if err := os.MkdirAll(filepath.Join(tmHome, sub), nodeDirPerm); err != nil {
+				return nil, err
+			}
+		}
+		commands.InitFilesWithConfig(config)
+		consensus.LoadFileBS(filepath.Join(tmHome, config.BaseConfig.BlsState))
+		nodeKey, err := p2p.LoadOrGenNodeKey(config.NodeKeyFile())
+		if err != nil {
+			return nil, err
+		}
+
+		// the signer key has an empty password, a devnet holds no value
+		ks := keystore.NewKeyStore(filepath.Join(dataDir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+		signer, err := ks.NewAccount("")
+		if err != nil {
+			return nil, err
+		}
+
+		spec.Validators = append(spec.Validators, GenesisValidatorSpec{
+			Name:    name,
+			Home:    tmHome,
+			Power:   1,
+			Signer:  signer.Address.Hex(),
+			Balance: balance.String(),
+		})
+		state.Nodes = append(state.Nodes, &devnetNode{
+			Name:    name,
+			DataDir: dataDir,
+			NodeID:  string(nodeKey.ID()),
+			Ports:   newDevnetPorts(basePort, i),
+		})
+	}
+
+	output, err := buildGenesis(spec, dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := output.save(dir); err != nil {
+		return nil, err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range state.Nodes {
+		tmConfigDir := filepath.Join(node.DataDir, "tendermint", "config")
+		if err := output.tmGenesis.SaveAs(filepath.Join(tmConfigDir, "genesis.json")); err != nil {
+			return nil, err
+		}
+		if err := copyFile(filepath.Join(dir, initialAccountFileName), filepath.Join(tmConfigDir, initialAccountFileName)); err != nil {
+			return nil, err
+		}
+		// dtfn init writes the genesis block, ethermintCmd reads the genesis from the chaindata dir
+		out, err := exec.Command(self, "--datadir", node.DataDir, "init", filepath.Join(dir, ethGenesisFileName)).CombinedOutput()
+		if err != nil {
+			return nil, fmt.Errorf("%v init failed: %v\n%s", node.Name, err, out)
+		}
+		if err := copyFile(filepath.Join(dir, ethGenesisFileName), filepath.Join(node.DataDir, "gelchain/chaindata/genesis.json")); err != nil {
+			return nil, err
+		}
+	}
+	return state, state.save(dir)
+}
+
+// devnetNodeArgs returns the command line of a node, all its listeners are on the loopback
+func devnetNodeArgs(state *devnetState, node *devnetNode) []string {
+	var peers []string
+	for _, peer := range state.Nodes {
+		if peer != node {
+			peers = append(peers, fmt.Sprintf("%v@127.0.0.1:%v", peer.NodeID, peer.Ports.P2P))
+		}
+	}
+	return []string{
+		"--datadir", node.DataDir,
+		"--with-tendermint",
+		"--priv_validator_file", "config/priv_validator_key.json",
+		"--addr_book_file", "config/addrbook.json",
+		"--initial_eth_account", "config/" + initialAccountFileName,
+		"--persistent_peers", strings.Join(peers, ","),
+		"--tendermint_p2paddr", fmt.Sprintf("tcp://127.0.0.1:%v", node.Ports.P2P),
+		"--tendermint_rpcaddr", fmt.Sprintf("tcp://127.0.0.1:%v", node.Ports.TmRPC),
+		"--tendermint_addr", fmt.Sprintf("tcp://127.0.0.1:%v", node.Ports.TmRPC),
+		"--abci_laddr", fmt.Sprintf("tcp://127.0.0.1:%v", node.Ports.ABCI),
+		"--http_laddr", fmt.Sprintf("127.0.0.1:%v", node.Ports.HTTP),
+		"--rpc", "--rpcaddr", "127.0.0.1", "--rpcport", strconv.Itoa(node.Ports.EthRPC),
+		"--rpcapi", "eth,net,web3,personal,admin,txpool,dtfn",
+		"--ws", "--wsaddr", "127.0.0.1", "--wsport", strconv.Itoa(node.Ports.WS),
+		"--routable_strict=false",
+		"--fast_sync=true",
+	}
+}
+
+// startDevnet starts the nodes which are not running yet, in their own process group
+// so that interrupting the log tail does not stop them
+func startDevnet(state *devnetState) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	for _, node := range state.Nodes {
+		if processAlive(node.Pid) {
+			fmt.Printf("%v is already running with pid %v\n", node.Name, node.Pid)
+			continue
+		}
+		logFile, err := os.OpenFile(node.logFile(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		cmd := exec.Command(self, devnetNodeArgs(state, node)...)
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		err = cmd.Start()
+		logFile.Close()
+		if err != nil {
+			return fmt.Errorf("failed to start %v: %v", node.Name, err)
+		}
+		node.Pid = cmd.Process.Pid
+		cmd.Process.Release()
+	}
+	return nil
+}
+
+// stopDevnet sends SIGTERM to the running nodes and kills those still alive after devnetStopTimeout
+func stopDevnet(state *devnetState) {
+	for _, node := range state.Nodes {
+		if processAlive(node.Pid) {
+			fmt.Printf("Stopping %v (pid %v)\n", node.Name, node.Pid)
+			syscall.Kill(node.Pid, syscall.SIGTERM)
+		}
+	}
+	deadline := time.Now().Add(devnetStopTimeout)
+	for _, node := range state.Nodes {
+		for processAlive(node.Pid) && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if processAlive(node.Pid) {
+			fmt.Printf("Killing %v (pid %v)\n", node.Name, node.Pid)
+			syscall.Kill(node.Pid, syscall.SIGKILL)
+		}
+		node.Pid = 0
+	}
+}
+
+func processAlive(pid int) bool {
+	return pid > 0 && syscall.Kill(pid, 0) == nil
+}
+
+// tailDevnetLogs prints the new lines of every node log prefixed by the node name, until ctrl-c
+func tailDevnetLogs(state *devnetState) error {
+	quit := make(chan struct{})
+	lines := make(chan string, 1024)
+	var wg sync.WaitGroup
+	for _, node := range state.Nodes {
+		wg.Add(1)
+		go func(node *devnetNode) {
+			defer wg.Done()
+			tailFile(node.logFile(), node.Name, lines, quit)
+		}(node)
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	for {
+		select {
+		case line := <-lines:
+			fmt.Println(line)
+		case <-sigs:
+			close(quit)
+			wg.Wait()
+			return nil
+		}
+	}
+}
+
+func tailFile(path string, name string, lines chan<- string, quit <-chan struct{}) {
+	var file *os.File
+	for file == nil {
+		var err error
+		if file, err = os.Open(path); err != nil {
+			select {
+			case <-quit:
+				return
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+	}
+	defer file.Close()
+	file.Seek(0, io.SeekEnd)
+	reader := bufio.NewReader(file)
+	partial := ""
+	for {
+		line, err := reader.ReadString('\n')
+		partial += line
+		if err == nil {
+			select {
+			case lines <- fmt.Sprintf("[%v] %v", name, strings.TrimRight(partial, "\n")):
+			case <-quit:
+				return
+			}
+			partial = ""
+			continue
+		}
+		select {
+		case <-quit:
+			return
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+func copyFile(src string, dst string) error {
+	content, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), nodeDirPerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, content, 0644)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/node"
+
+	emtUtils "github.com/DTFN/dtfn/cmd/utils"
+)
+
+func TestDevnetPortsDoNotCollide(t *testing.T) {
+	const n = 16
+	used := make(map[int]string)
+	for i := 0; i < n; i++ {
+		ports := reflect.ValueOf(newDevnetPorts(36000, i))
+		for f := 0; f < ports.NumField(); f++ {
+			port := int(ports.Field(f).Int())
+			name := ports.Type().Field(f).Name
+			if owner, ok := used[port]; ok {
+				t.Fatalf("node%d %v port %v already used by %v", i, name, port, owner)
+			}
+			used[port] = name
+		}
+		if ports.NumField() > devnetPortsPerNode {
+			t.Fatalf("%v ports per node, only %v reserved", ports.NumField(), devnetPortsPerNode)
+		}
+	}
+}
+
+func TestDevnetNodeArgs(t *testing.T) {
+	state := &devnetState{}
+	for i := 0; i < 2; i++ {
+		state.Nodes = append(state.Nodes, &devnetNode{Ports: newDevnetPorts(36000, i)})
+	}
+	args := devnetNodeArgs(state, state.Nodes[0])
+	for i, arg := range args {
+		if arg == "--rpcapi" && !strings.Contains(args[i+1], "dtfn") {
+			t.Errorf("the dtfn rpc is not served over http: %v", args[i+1])
+		}
+	}
+
+	// the geth p2p listener would bind the same default port in every node
+	config := node.DefaultConfig
+	emtUtils.SetEthermintNodeConfig(&config)
+	if config.P2P.ListenAddr != "" {
+		t.Errorf("geth p2p listens on %v", config.P2P.ListenAddr)
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/DTFN/dtfn/version"
 	"os"
@@ -12,14 +11,13 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	ethUtils "github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txfilter"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/log"
 	"gopkg.in/urfave/cli.v1"
 
 	"github.com/tendermint/tendermint/abci/server"
 
-	cmn "github.com/tendermint/tendermint/libs/common"
-
 	abciApp "github.com/DTFN/dtfn/app"
 	emtUtils "github.com/DTFN/dtfn/cmd/utils"
 	"github.com/DTFN/dtfn/ethereum"
@@ -55,7 +53,9 @@ func ethermintCmd(ctx *cli.Context) error {
 	case 3:
 		version.LoadProductionConfig(conf)
 	}
-	version.InitConfig()
+	if err := version.InitConfig(); err != nil {
+		ethUtils.Fatalf("Failed to load the version config: %v", err)
+	}
 
 	// Step 1: Setup the go-ethereum node and start it
 	node, backend := emtUtils.MakeFullNode(ctx)
@@ -85,12 +85,22 @@ func ethermintCmd(ctx *cli.Context) error {
 	ethLogger := tmlog.NewTMLogger(tmlog.NewSyncWriter(os.Stdout)).With("module", "gelchain")
 	configLoggerLevel(ctx, &ethLogger)
 	ethApp.SetLogger(ethLogger)
+	ethApp.SetMinGasPrice(big.NewInt(ctx.GlobalInt64(emtUtils.MinGasPrice.Name)))
+	ethApp.SetHttpServerAddr(ctx.GlobalString(emtUtils.HttpServerAddrFlag.Name))
+	backend.SetHealthConfig(healthConfig(ctx))
+	backend.TxTracker().SetRetention(time.Duration(ctx.GlobalInt(emtUtils.TxStatusRetentionFlag.Name)) * time.Second)
 
 	ethLogger.Info("version.config", "version.HeightString", version.HeightString,
 		"version.VersionString", version.VersionString, "version.Bigguy", version.Bigguy,
 		"version.PPChainAdmin", version.PPChainAdmin,
 		"version.PPChainPrivateAdmin", version.PPChainPrivateAdmin,
-		"version.EvmErrHardForkHeight", version.EvmErrHardForkHeight)
+		"version.EvmErrHardForkHeight", version.EvmErrHardForkHeight,
+		"version.MinGasPriceHeight", version.MinGasPriceHeight, "version.MinGasPrice", version.MinGasPriceString,
+		"version.BaseFeeHeight", version.BaseFeeHeight,
+		"version.BlockGasLimit", version.BlockGasLimit, "version.BlockGasLimitHeight", version.BlockGasLimitHeight)
+	if ctx.GlobalIsSet(emtUtils.TargetGasLimitFlag.Name) {
+		ethLogger.Error("target_gas_limit is ignored, the block gas limit is a consensus parameter")
+	}
 
 	tmConfig := loadTMConfig(ctx)
 
@@ -109,31 +119,27 @@ func ethermintCmd(ctx *cli.Context) error {
 
 		ethAccounts, err := types.GetInitialEthAccountFromFile(tmConfig.InitialEthAccountFile())
 		if err != nil {
-			panic("Sorry but you don't have initial account file")
+			return fmt.Errorf("cannot read initial eth account file %v: %v", tmConfig.InitialEthAccountFile(), err)
 		}
 
 		genDocFile := tmConfig.GenesisFile()
 		genDoc, err := tmState.MakeGenesisDocFromFile(genDocFile)
-		validators := genDoc.Validators
-		amlist := &types.AccountMap{
-			MapList: make(map[string]*types.AccountMapItem),
+		if err != nil {
+			return err
 		}
-		log.Info(fmt.Sprintf("get Initial accountMap len %v. genDoc.Validators len %v",
-			len(ethAccounts.EthAccounts), len(validators)))
-		for i := 0; i < len(validators); i++ {
-			tmAddress := validators[i].PubKey.Address().String()
-			blsKey := validators[i].BlsPubKey
-			blsKeyJsonStr, _ := json.Marshal(blsKey)
-			/*		accountBalance := big.NewInt(1)
-					accountBalance.Div(totalBalanceInital, big.NewInt(100))*/
-			if i == len(ethAccounts.EthAccounts) {
-				break
-			}
-			amlist.MapList[tmAddress] = &types.AccountMapItem{
-				common.HexToAddress(ethAccounts.EthAccounts[i]),
-				common.HexToAddress(ethAccounts.EthBeneficiarys[i]), //10个eth账户中的第i个。
-				string(blsKeyJsonStr),
+		validators := genDoc.Validators
+		log.Info(fmt.Sprintf("get Initial accountMap version %v. genDoc.Validators len %v",
+			ethAccounts.Version, len(validators)))
+		// same threshold as SetPosTableThreshold at InitChain
+		threshold := new(big.Int).Div(totalBalanceInital, big.NewInt(txfilter.ThresholdUnit))
+		amlist, err := ethAccounts.InitialAccountMap(validators, func(signer common.Address) *big.Int {
+			if account, ok := genesis.Alloc[signer]; ok {
+				return account.Balance
 			}
+			return nil
+		}, threshold)
+		if err != nil {
+			return err
 		}
 
 		strategy.SetInitialAccountMap(amlist)
@@ -198,6 +204,7 @@ func ethermintCmd(ctx *cli.Context) error {
 			return err
 		}
 
+		backend.SetTendermintNode(n)
 		memPool := n.Mempool()
 		backend.SetMemPool(memPool)
 		clist_mempool := memPool.(*mempool.CListMempool)
@@ -208,12 +215,6 @@ func ethermintCmd(ctx *cli.Context) error {
 			log.Error("server with tendermint start", "error", err)
 			return err
 		}
-		// Stop upon receiving SIGTERM or CTRL-C.
-		cmn.TrapSignal(tmLogger, func() {
-			if n.IsRunning() {
-				n.Stop()
-			}
-		})
 
 		/*			h := new(memsizeui.Handler)
 					s := &http.Server{Addr: "0.0.0.0:9090", Handler: h}
@@ -229,8 +230,8 @@ func ethermintCmd(ctx *cli.Context) error {
 					txPool.DebugMemory(h)
 					go s.ListenAndServe()*/
 
-		// Run forever.
-		select {}
+		// Run until SIGTERM or CTRL-C.
+		newShutdown(ctx, node, backend, ethApp, n, tmLogger).wait()
 		return nil
 	} else {
 		// Start the app on the ABCI server
@@ -248,16 +249,27 @@ func ethermintCmd(ctx *cli.Context) error {
 			os.Exit(1)
 		}
 
-		cmn.TrapSignal(logger, func() {
-			if srv.IsRunning() {
-				srv.Stop()
-			}
-		})
-		// Run forever.
-		select {}
+		// Run until SIGTERM or CTRL-C.
+		newShutdown(ctx, node, backend, ethApp, srv, logger).wait()
+		return nil
 	}
+}
 
-	return nil
+// healthConfig reads the thresholds of the health checks, without an explicit latest block age
+// it is derived from the empty blocks interval, tendermint makes no block on an idle chain otherwise
+func healthConfig(ctx *cli.Context) ethereum.HealthConfig {
+	config := ethereum.DefaultHealthConfig()
+	config.MaxCommitDuration = time.Duration(ctx.GlobalInt(emtUtils.HealthCommitTimeoutFlag.Name)) * time.Second
+	config.MaxTxpoolBacklog = ctx.GlobalInt(emtUtils.HealthTxpoolBacklogFlag.Name)
+	if age := ctx.GlobalInt(emtUtils.HealthCommitAgeFlag.Name); age > 0 {
+		config.MaxCommitAge = time.Duration(age) * time.Second
+	} else if ctx.GlobalBool(emtUtils.TmConsEmptyBlock.Name) {
+		config.MaxCommitAge = 4 * time.Duration(ctx.GlobalInt(emtUtils.TmConsEBlockInteval.Name)) * time.Second
+		if config.MaxCommitAge < time.Minute {
+			config.MaxCommitAge = time.Minute
+		}
+	}
+	return config
 }
 
 //加载tendermint相关的配置
@@ -289,6 +301,8 @@ func loadTMConfig(ctx *cli.Context) *tmcfg.Config {
 
 	tmConfig.Instrumentation = DefaultInstrumentationConfig
 
+	tmConfig.TxIndex.IndexKeys = ctx.GlobalString(emtUtils.TxIndexKeys.Name)
+
 	tmConfig.FastSync = ctx.GlobalBool(emtUtils.FastSync.Name)
 	tmConfig.BaseConfig.InitialEthAccount = ctx.GlobalString(emtUtils.TmInitialEthAccount.Name)
 	tmConfig.PrivValidatorListenAddr = ctx.GlobalString(emtUtils.PrivValidatorListenAddr.Name)
@@ -299,6 +313,7 @@ func loadTMConfig(ctx *cli.Context) *tmcfg.Config {
 	tmConfig.P2P.PersistentPeers = ctx.GlobalString(emtUtils.PersistentPeers.Name)
 	tmConfig.P2P.PrivatePeerIDs = ctx.GlobalString(emtUtils.PrivatePeerIDs.Name)
 	tmConfig.P2P.ListenAddress = ctx.GlobalString(emtUtils.TendermintP2PListenAddress.Name)
+	tmConfig.RPC.ListenAddress = ctx.GlobalString(emtUtils.TendermintRPCListenAddress.Name)
 	tmConfig.P2P.ExternalAddress = ctx.GlobalString(emtUtils.TendermintP2PExternalAddress.Name)
 	tmConfig.P2P.MaxNumInboundPeers = ctx.GlobalInt(emtUtils.MaxInPeers.Name)
 	tmConfig.P2P.MaxNumOutboundPeers = ctx.GlobalInt(emtUtils.MaxInPeers.Name)
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txfilter"
+	"github.com/ethereum/go-ethereum/params"
+	cfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/consensus"
+	"github.com/tendermint/tendermint/privval"
+	tmTypes "github.com/tendermint/tendermint/types"
+	"gopkg.in/urfave/cli.v1"
+	"gopkg.in/yaml.v2"
+
+	"github.com/DTFN/dtfn/types"
+)
+
+const (
+	ethGenesisFileName     = "eth_genesis.json"
+	tmGenesisFileName      = "tm_genesis.json"
+	initialAccountFileName = "initial_eth_account.json"
+)
+
+var (
+	genesisOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Value: ".",
+		Usage: "directory receiving " + ethGenesisFileName + ", " + tmGenesisFileName + " and " + initialAccountFileName,
+	}
+
+	genesisCommand = cli.Command{
+		Action:    genesisCmd,
+		Name:      "genesis",
+		Usage:     "build the eth genesis, the tendermint genesis and the initial account file from one yaml spec",
+		ArgsUsage: "<spec.yaml>",
+		Flags:     []cli.Flag{genesisOutputFlag},
+	}
+)
+
+// GenesisSpec is the declarative description of a chain read by `dtfn genesis`
+type GenesisSpec struct {
+	ChainID     string                 `yaml:"chain_id"`
+	GenesisTime string                 `yaml:"genesis_time"`
+	Eth         EthGenesisSpec         `yaml:"eth"`
+	Validators  []GenesisValidatorSpec `yaml:"validators"`
+	Accounts    []GenesisAccountSpec   `yaml:"accounts"`
+}
+
+type EthGenesisSpec struct {
+	ChainID    int64  `yaml:"chain_id"`
+	GasLimit   uint64 `yaml:"gas_limit"`
+	Difficulty string `yaml:"difficulty"`
+	Nonce      uint64 `yaml:"nonce"`
+	Timestamp  uint64 `yaml:"timestamp"`
+	ExtraData  string `yaml:"extra_data"`
+}
+
+// GenesisValidatorSpec points to the tendermint home holding the keys of a validator,
+// and gives the eth accounts bonded with them
+type GenesisValidatorSpec struct {
+	Name        string `yaml:"name"`
+	Home        string `yaml:"home"`
+	Power       int64  `yaml:"power"`
+	Signer      string `yaml:"signer"`
+	Beneficiary string `yaml:"beneficiary"`
+	Balance     string `yaml:"balance"`
+}
+
+type GenesisAccountSpec struct {
+	Address string `yaml:"address"`
+	Balance string `yaml:"balance"`
+}
+
+// genesisOutput holds the three files built from a GenesisSpec
+type genesisOutput struct {
+	ethGenesis  *core.Genesis
+	tmGenesis   *tmTypes.GenesisDoc
+	ethAccounts *types.EthAccounts
+}
+
+func genesisCmd(ctx *cli.Context) error {
+	specPath := ctx.Args().First()
+	if specPath == "" {
+		return fmt.Errorf("usage: %v genesis %v", app.Name, genesisCommand.ArgsUsage)
+	}
+	spec, err := readGenesisSpec(specPath)
+	if err != nil {
+		return err
+	}
+	// relative homes are resolved against the spec, not the working directory
+	output, err := buildGenesis(spec, filepath.Dir(specPath))
+	if err != nil {
+		return err
+	}
+	outputDir := ctx.String(genesisOutputFlag.Name)
+	if err := output.save(outputDir); err != nil {
+		return err
+	}
+	fmt.Printf("Successfully wrote the genesis of %v validators and %v accounts to %v\n",
+		len(output.tmGenesis.Validators), len(output.ethGenesis.Alloc), outputDir)
+	return nil
+}
+
+func readGenesisSpec(specPath string) (*GenesisSpec, error) {
+	yamlFile, err := ioutil.ReadFile(specPath)
+	if err != nil {
+		return nil, err
+	}
+	spec := &GenesisSpec{}
+	if err := yaml.UnmarshalStrict(yamlFile, spec); err != nil {
+		return nil, fmt.Errorf("invalid genesis spec %v: %v", specPath, err)
+	}
+	return spec, nil
+}
+
+// buildGenesis validates the spec and builds the genesis files. Every problem found is reported at once.
+// The output only depends on the spec and the keys, so building it twice gives the same files.
+func buildGenesis(spec *GenesisSpec, baseDir string) (*genesisOutput, error) {
+	var problems []string
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if spec.ChainID == "" {
+		report("chain_id is required")
+	}
+	genesisTime, err := time.Parse(time.RFC3339, spec.GenesisTime)
+	if err != nil {
+		report("genesis_time must be an RFC3339 time: %v", err)
+	}
+	if spec.Eth.ChainID <= 0 {
+		report("eth.chain_id must be positive")
+	}
+	difficulty := big.NewInt(0x40)
+	if spec.Eth.Difficulty != "" {
+		var ok bool
+		if difficulty, ok = parseGenesisAmount(spec.Eth.Difficulty); !ok {
+			report("invalid eth.difficulty %v", spec.Eth.Difficulty)
+		}
+	}
+	extraData, err := hexutil.Decode(orHexPrefix(spec.Eth.ExtraData))
+	if err != nil {
+		report("invalid eth.extra_data: %v", err)
+	}
+	if len(spec.Validators) == 0 {
+		report("at least one validator is required")
+	}
+
+	alloc := core.GenesisAlloc{}
+	addBalance := func(owner string, address common.Address, balance *big.Int) {
+		if _, found := alloc[address]; found {
+			report("%v: account %v is allocated twice", owner, address.Hex())
+			return
+		}
+		alloc[address] = core.GenesisAccount{Balance: balance}
+	}
+
+	ethAccounts := &types.EthAccounts{
+		Version:  types.InitialEthAccountVersion,
+		Accounts: make(map[string]*types.InitialEthAccount),
+	}
+	signerBalances := make([]*big.Int, 0, len(spec.Validators))
+	genVals := make([]tmTypes.GenesisValidator, 0, len(spec.Validators))
+	tmAddresses := make(map[string]string)
+	for i, val := range spec.Validators {
+		owner := fmt.Sprintf("validators[%d]", i)
+		if val.Name != "" {
+			owner = val.Name
+		}
+		if !common.IsHexAddress(val.Signer) {
+			report("%v: invalid signer %q", owner, val.Signer)
+			continue
+		}
+		signer := common.HexToAddress(val.Signer)
+		beneficiary := signer
+		if val.Beneficiary != "" {
+			if !common.IsHexAddress(val.Beneficiary) {
+				report("%v: invalid beneficiary %q", owner, val.Beneficiary)
+				continue
+			}
+			beneficiary = common.HexToAddress(val.Beneficiary)
+		}
+		balance, ok := parseGenesisAmount(val.Balance)
+		if !ok || balance.Sign() <= 0 {
+			report("%v: signer balance must be a positive amount, got %q", owner, val.Balance)
+			continue
+		}
+		power := val.Power
+		if power == 0 {
+			power = 1
+		}
+		genVal, err := loadGenesisValidator(resolvePath(baseDir, val.Home), owner, power)
+		if err != nil {
+			report("%v: %v", owner, err)
+			continue
+		}
+		tmAddress := genVal.PubKey.Address().String()
+		if other, found := tmAddresses[tmAddress]; found {
+			report("%v: tendermint key %v is already used by %v", owner, tmAddress, other)
+			continue
+		}
+		tmAddresses[tmAddress] = owner
+
+		blsKeyJSON, err := json.Marshal(genVal.BlsPubKey)
+		if err != nil {
+			report("%v: invalid bls key: %v", owner, err)
+			continue
+		}
+		addBalance(owner, signer, balance)
+		genVals = append(genVals, genVal)
+		signerBalances = append(signerBalances, balance)
+		ethAccounts.Accounts[tmAddress] = &types.InitialEthAccount{
+			Signer:       signer,
+			Beneficiary:  beneficiary,
+			BlsKeyString: string(blsKeyJSON),
+		}
+	}
+	for i, account := range spec.Accounts {
+		owner := fmt.Sprintf("accounts[%d]", i)
+		if !common.IsHexAddress(account.Address) {
+			report("%v: invalid address %q", owner, account.Address)
+			continue
+		}
+		balance, ok := parseGenesisAmount(account.Balance)
+		if !ok || balance.Sign() < 0 {
+			report("%v: invalid balance %q", owner, account.Balance)
+			continue
+		}
+		addBalance(owner, common.HexToAddress(account.Address), balance)
+	}
+
+	// every tendermint validator needs its initial account at startup
+	if len(genVals) != len(spec.Validators) || len(ethAccounts.Accounts) != len(genVals) {
+		report("only %v of the %v validators have usable keys and accounts", len(genVals), len(spec.Validators))
+	}
+
+	// same threshold as app.SetPosTableThreshold, a signer below it gets no slot at InitChain
+	totalBalance := big.NewInt(0)
+	for _, account := range alloc {
+		totalBalance.Add(totalBalance, account.Balance)
+	}
+	threshold := new(big.Int).Div(totalBalance, big.NewInt(txfilter.ThresholdUnit))
+	for i, genVal := range genVals {
+		if signerBalances[i].Cmp(threshold) < 0 {
+			report("%v: signer %v balance %v is below the PosTable threshold %v (total balance %v / ThresholdUnit %v)",
+				genVal.Name, ethAccounts.Accounts[genVal.PubKey.Address().String()].Signer.Hex(),
+				signerBalances[i], threshold, totalBalance, txfilter.ThresholdUnit)
+		}
+	}
+
+	if len(problems) != 0 {
+		return nil, fmt.Errorf("invalid genesis spec:\n  %v", strings.Join(problems, "\n  "))
+	}
+
+	tmGenesis := &tmTypes.GenesisDoc{
+		GenesisTime: genesisTime.UTC(),
+		ChainID:     spec.ChainID,
+		Validators:  genVals,
+	}
+	if err := tmGenesis.ValidateAndComplete(); err != nil {
+		return nil, fmt.Errorf("invalid tendermint genesis: %v", err)
+	}
+	ethGenesis := &core.Genesis{
+		Config: &params.ChainConfig{
+			ChainID:        big.NewInt(spec.Eth.ChainID),
+			HomesteadBlock: big.NewInt(0),
+			EIP155Block:    big.NewInt(0),
+			EIP158Block:    big.NewInt(0),
+		},
+		Nonce:      spec.Eth.Nonce,
+		Timestamp:  spec.Eth.Timestamp,
+		ExtraData:  extraData,
+		GasLimit:   spec.Eth.GasLimit,
+		Difficulty: difficulty,
+		Alloc:      alloc,
+	}
+	if ethGenesis.GasLimit == 0 {
+		ethGenesis.GasLimit = params.GenesisGasLimit
+	}
+	return &genesisOutput{
+		ethGenesis:  ethGenesis,
+		tmGenesis:   tmGenesis,
+		ethAccounts: ethAccounts,
+	}, nil
+}
+
+// loadGenesisValidator reads the tendermint and bls public keys from an initialized tendermint home
+func loadGenesisValidator(home string, name string, power int64) (tmTypes.GenesisValidator, error) {
+	if home == "" {
+		return tmTypes.GenesisValidator{}, fmt.Errorf("home is required")
+	}
+	config := cfg.DefaultConfig()
+	config.SetRoot(home)
+	keyFile := config.PrivValidatorKeyFile()
+	if _, err := os.Stat(keyFile); err != nil {
+		return tmTypes.GenesisValidator{}, fmt.Errorf("missing tendermint key: %v", err)
+	}
+	blsFile := filepath.Join(home, config.BaseConfig.BlsState)
+	if _, err := os.Stat(blsFile); err != nil {
+		return tmTypes.GenesisValidator{}, fmt.Errorf("missing bls key: %v", err)
+	}
+	pv := privval.LoadFilePVEmptyState(keyFile, config.PrivValidatorStateFile())
+	blsState := consensus.LoadFileBS(blsFile)
+	return tmTypes.GenesisValidator{
+		PubKey: pv.GetPubKey(),
+		BlsPubKey: tmTypes.BLSPubKey{
+			Type:    "Secp256k1",
+			Address: pv.GetPubKey().Address().String(),
+			Value:   blsState.GetPubKPKE(),
+		},
+		Power: power,
+		Name:  name,
+	}, nil
+}
+
+func (output *genesisOutput) save(outputDir string) error {
+	if err := os.MkdirAll(outputDir, nodeDirPerm); err != nil {
+		return err
+	}
+	ethGenesisJSON, err := json.MarshalIndent(output.ethGenesis, "", "  ")
+	if err != nil {
+		return err
+	}
+	ethAccountsJSON, err := json.MarshalIndent(output.ethAccounts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(outputDir, ethGenesisFileName), ethGenesisJSON, 0644); err != nil {
+		return err
+	}
+	if err := output.tmGenesis.SaveAs(filepath.Join(outputDir, tmGenesisFileName)); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(outputDir, initialAccountFileName), ethAccountsJSON, 0644)
+}
+
+// parseGenesisAmount accepts decimal or 0x prefixed hex amounts
+func parseGenesisAmount(amount string) (*big.Int, bool) {
+	amount = strings.TrimSpace(amount)
+	if strings.HasPrefix(amount, "0x") || strings.HasPrefix(amount, "0X") {
+		return new(big.Int).SetString(amount[2:], 16)
+	}
+	return new(big.Int).SetString(amount, 10)
+}
+
+func orHexPrefix(s string) string {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return s
+	}
+	return "0x" + s
+}
+
+func resolvePath(baseDir string, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tmcfg "github.com/tendermint/tendermint/config"
+)
+
+// genesisTestHome generates the keys of a validator in a temporary tendermint home
+func genesisTestHome(t *testing.T, dir string, name string) string {
+	home := filepath.Join(dir, name)
+	tmConfig := tmcfg.DefaultConfig()
+	tmConfig.SetRoot(home)
+	if err := genValidatorKeys(tmConfig); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+func TestGenesisDeterministic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dtfn-genesis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	spec, err := readGenesisSpec(filepath.Join("..", "..", "setup", "genesis.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range spec.Validators {
+		spec.Validators[i].Home = genesisTestHome(t, dir, spec.Validators[i].Name)
+	}
+
+	var outputDirs []string
+	for i := 0; i < 2; i++ {
+		output, err := buildGenesis(spec, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputDir := filepath.Join(dir, fmt.Sprint("output", i))
+		if err := output.save(outputDir); err != nil {
+			t.Fatal(err)
+		}
+		outputDirs = append(outputDirs, outputDir)
+	}
+	for _, file := range []string{ethGenesisFileName, tmGenesisFileName, initialAccountFileName} {
+		first, err := ioutil.ReadFile(filepath.Join(outputDirs[0], file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := ioutil.ReadFile(filepath.Join(outputDirs[1], file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(first, second) {
+			t.Errorf("%v differs between two builds:\n%s\n%s", file, first, second)
+		}
+	}
+}
+
+func TestGenesisValidation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dtfn-genesis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	home := genesisTestHome(t, dir, "node0")
+
+	newSpec := func() *GenesisSpec {
+		return &GenesisSpec{
+			ChainID:     "dtfn-test",
+			GenesisTime: "2020-01-01T00:00:00Z",
+			Eth:         EthGenesisSpec{ChainID: 15},
+			Validators: []GenesisValidatorSpec{{
+				Name:    "node0",
+				Home:    home,
+				Signer:  "0x7eff122b94897ea5b0e2a9abf47b86337fafebdc",
+				Balance: "1000000000000000000",
+			}},
+		}
+	}
+	if _, err := buildGenesis(newSpec(), dir); err != nil {
+		t.Fatalf("valid spec: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(spec *GenesisSpec)
+		problems []string
+	}{
+		{
+			name: "signer below the threshold",
+			modify: func(spec *GenesisSpec) {
+				spec.Accounts = append(spec.Accounts, GenesisAccountSpec{
+					Address: "0xc6713982649D9284ff56c32655a9ECcCDA78422A",
+					Balance: "1000000000000000000000000000000",
+				})
+			},
+			problems: []string{"node0: signer", "below the PosTable threshold"},
+		},
+		{
+			name: "missing keys",
+			modify: func(spec *GenesisSpec) {
+				spec.Validators[0].Home = filepath.Join(dir, "missing")
+			},
+			problems: []string{"node0: missing tendermint key", "only 0 of the 1 validators have usable keys and accounts"},
+		},
+		{
+			name: "shared keys",
+			modify: func(spec *GenesisSpec) {
+				other := spec.Validators[0]
+				other.Name = "node1"
+				other.Signer = "0xc6713982649D9284ff56c32655a9ECcCDA78422A"
+				spec.Validators = append(spec.Validators, other)
+			},
+			problems: []string{"node1: tendermint key", "is already used by node0", "only 1 of the 2 validators have usable keys and accounts"},
+		},
+	}
+	for _, test := range tests {
+		spec := newSpec()
+		test.modify(spec)
+		_, err := buildGenesis(spec, dir)
+		if err == nil {
+			t.Errorf("%v: expecting an error", test.name)
+			continue
+		}
+		for _, problem := range test.problems {
+			if !strings.Contains(err.Error(), problem) {
+				t.Errorf("%v: %q does not report %q", test.name, err, problem)
+			}
+		}
+	}
+}
@@ -40,6 +40,7 @@ var (
 		utils.TargetGasLimitFlag,
 		utils.TxpoolThreshold,
 		utils.TxpoolPriceLimit,
+		utils.MinGasPrice,
 		utils.LRUCacheSize,
 		ethUtils.InsecureUnlockAllowedFlag,
 		ethUtils.MaxPeersFlag,
@@ -65,6 +66,12 @@ var (
 		utils.TendermintAddrFlag,
 		utils.ABCIAddrFlag,
 		utils.ABCIProtocolFlag,
+		utils.HttpServerAddrFlag,
+		utils.HealthCommitAgeFlag,
+		utils.HealthCommitTimeoutFlag,
+		utils.HealthTxpoolBacklogFlag,
+		utils.TxStatusRetentionFlag,
+		utils.ShutdownTimeoutFlag,
 		utils.VerbosityFlag,
 		utils.ConfigFileFlag,
 		utils.WithTendermintFlag,
@@ -85,8 +92,10 @@ var (
 		utils.RoutabilityStrict,
 		utils.PrivatePeerIDs,
 		utils.TendermintP2PListenAddress,
+		utils.TendermintRPCListenAddress,
 		utils.TendermintP2PExternalAddress,
 		utils.MempoolBroadcastFlag,
+		utils.TxIndexKeys,
 		utils.TmConsEmptyBlock,
 		utils.TmConsEBlockInteval,
 		utils.TmConsNeedProofBlock,
@@ -155,6 +164,10 @@ func init() {
 			Name:   "testnet",
 			Usage:  "generate ,the test config file",
 		},
+		validatorCommand,
+		genesisCommand,
+		devnetCommand,
+		verifyReceiptsCommand,
 	}
 
 	app.Flags = append(app.Flags, nodeFlags...)
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	cmn "github.com/tendermint/tendermint/libs/common"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	"gopkg.in/urfave/cli.v1"
+
+	abciApp "github.com/DTFN/dtfn/app"
+	emtUtils "github.com/DTFN/dtfn/cmd/utils"
+	"github.com/DTFN/dtfn/ethereum"
+)
+
+// shutdown stops the node in order on SIGINT or SIGTERM, so that no block is left half written:
+//  1. the dtfn rpc refuses the txs, the Broadcaster stops and answers the txs of the txpool with an error
+//  2. tendermint, or the abci server, stops and the block in progress runs up to the end of its Commit
+//  3. the http server stops
+//  4. the blockchain writes its trie cache, gcmode archive has none, and the chain db is closed
+//  5. the geth stack closes
+type shutdown struct {
+	timeout time.Duration
+
+	stack     *ethereum.Node
+	backend   *ethereum.Backend
+	app       *abciApp.EthermintApplication
+	consensus cmn.Service // the tendermint node or the abci server
+
+	logger tmlog.Logger
+}
+
+func newShutdown(ctx *cli.Context, stack *ethereum.Node, backend *ethereum.Backend,
+	app *abciApp.EthermintApplication, consensus cmn.Service, logger tmlog.Logger) *shutdown {
+	return &shutdown{
+		timeout:   time.Duration(ctx.GlobalInt(emtUtils.ShutdownTimeoutFlag.Name)) * time.Second,
+		stack:     stack,
+		backend:   backend,
+		app:       app,
+		consensus: consensus,
+		logger:    logger,
+	}
+}
+
+// wait blocks until SIGINT or SIGTERM and stops the node. The process exits with 1
+// if the shutdown takes longer than the timeout or on a second signal.
+func (s *shutdown) wait() {
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+
+	sig := <-sigc
+	s.logger.Info("Got signal, shutting down", "signal", sig, "timeout", s.timeout)
+	done := make(chan struct{})
+	go func() {
+		s.stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.logger.Info("Shutdown complete")
+	case <-time.After(s.timeout):
+		s.logger.Error("Shutdown timed out, exiting", "timeout", s.timeout)
+		os.Exit(1)
+	case sig := <-sigc:
+		s.logger.Error("Got signal again, exiting", "signal", sig)
+		os.Exit(1)
+	}
+}
+
+func (s *shutdown) stop() {
+	s.logger.Info("Stopping the tx intake")
+	s.backend.StopTxIntake()
+
+	// the tendermint consensus reactor waits for its receive routine, a block in progress is committed first
+	if s.consensus.IsRunning() {
+		s.logger.Info("Stopping the consensus")
+		if err := s.consensus.Stop(); err != nil {
+			s.logger.Error("Failed to stop the consensus", "err", err)
+		}
+	}
+	// the abci server does not wait for the requests it runs, the app holds the next blocks back
+	s.logger.Info("Waiting for the block in progress")
+	s.app.StopBlocks()
+
+	if err := s.app.StopHttpServer(context.Background()); err != nil {
+		s.logger.Error("Failed to stop the http server", "err", err)
+	}
+
+	s.logger.Info("Closing the chain db")
+	s.backend.Stop() // nolint: errcheck
+
+	if err := s.stack.Close(); err != nil {
+		s.logger.Error("Failed to close the geth stack", "err", err)
+	}
+}
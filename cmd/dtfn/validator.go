@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	ethUtils "github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/txfilter"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rlp"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+	tmcfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/consensus"
+	cmn "github.com/tendermint/tendermint/libs/common"
+	"github.com/tendermint/tendermint/privval"
+	tmTypes "github.com/tendermint/tendermint/types"
+	"gopkg.in/urfave/cli.v1"
+
+	emtUtils "github.com/DTFN/dtfn/cmd/utils"
+)
+
+var (
+	validatorFromFlag = cli.StringFlag{
+		Name:  "from",
+		Usage: "signer account in the local keystore",
+	}
+	validatorBeneficiaryFlag = cli.StringFlag{
+		Name:  "beneficiary",
+		Usage: "account receiving the rewards, defaults to the signer",
+	}
+	validatorValueFlag = cli.StringFlag{
+		Name:  "value",
+		Value: "0",
+		Usage: "amount in wei sent with the tx",
+	}
+	validatorNonceFlag = cli.Int64Flag{
+		Name:  "nonce",
+		Value: -1,
+		Usage: "nonce of the tx, read from --rpc if not set",
+	}
+	validatorChainIDFlag = cli.Int64Flag{
+		Name:  "chain_id",
+		Usage: "chain id used to sign the tx, read from --rpc if not set",
+	}
+	validatorGasPriceFlag = cli.StringFlag{
+		Name:  "gas_price",
+		Usage: "gas price in wei, suggested by --rpc if not set",
+	}
+	validatorGasFlag = cli.Uint64Flag{
+		Name:  "gas",
+		Value: 100000,
+		Usage: "gas limit of the tx",
+	}
+	validatorRPCFlag = cli.StringFlag{
+		Name:  "rpc",
+		Usage: "local node rpc endpoint, e.g. http://127.0.0.1:8545. The command stays offline without it",
+	}
+	validatorSubmitFlag = cli.BoolFlag{
+		Name:  "submit",
+		Usage: "submit the signed tx through --rpc instead of only printing it",
+	}
+	validatorHTTPFlag = cli.StringFlag{
+		Name:  "http",
+		Value: "http://127.0.0.1:19190",
+		Usage: "dtfn http server of a node",
+	}
+
+	validatorTxFlags = []cli.Flag{
+		validatorFromFlag,
+		validatorBeneficiaryFlag,
+		validatorValueFlag,
+		validatorNonceFlag,
+		validatorChainIDFlag,
+		validatorGasPriceFlag,
+		validatorGasFlag,
+		validatorRPCFlag,
+		validatorSubmitFlag,
+	}
+
+	validatorCommand = cli.Command{
+		Name:  "validator",
+		Usage: "create, bond, unbond and inspect a validator",
+		Subcommands: []cli.Command{
+			{
+				Action: validatorCreateCmd,
+				Name:   "create",
+				Usage:  "generate the tendermint, bls and eth keys of a validator and print its bond data",
+				Flags:  []cli.Flag{validatorBeneficiaryFlag},
+			},
+			{
+				Action: validatorBondCmd,
+				Name:   "bond",
+				Usage:  "sign the bond tx of the local validator keys",
+				Flags:  validatorTxFlags,
+			},
+			{
+				Action: validatorUnbondCmd,
+				Name:   "unbond",
+				Usage:  "sign the unbond tx of a signer",
+				Flags:  validatorTxFlags,
+			},
+			{
+				Action: validatorStatusCmd,
+				Name:   "status",
+				Usage:  "show the PosItem, slots, unbond height and rewards of a signer",
+				Flags:  []cli.Flag{validatorFromFlag, validatorHTTPFlag},
+			},
+		},
+	}
+)
+
+// bondTxData is the payload of a tx to txfilter.SendToLock, it describes the PosItem to insert
+type bondTxData struct {
+	PubKey       abciTypes.PubKey `json:"pub_key"`
+	Beneficiary  string           `json:"beneficiary"`
+	BlsKeyString string           `json:"bls_key_string"`
+}
+
+// genValidatorKeys generates the tendermint and bls keys of the local node unless they exist
+func genValidatorKeys(tmConfig *tmcfg.Config) error {
+	if err := cmn.EnsureDir(filepath.Dir(tmConfig.PrivValidatorKeyFile()), nodeDirPerm); err != nil {
+		return fmt.Errorf("failed to create tendermint config dir: %v", err)
+	}
+	privval.LoadOrGenFilePV(tmConfig.PrivValidatorKeyFile(), tmConfig.PrivValidatorStateFile())
+	consensus.LoadFileBS(filepath.Join(tmConfig.RootDir, tmConfig.BaseConfig.BlsState))
+	return nil
+}
+
+// validatorKeys loads the tendermint and bls keys of the local node, `validator create` generates them
+func validatorKeys(tmConfig *tmcfg.Config) (abciTypes.PubKey, string, string, error) {
+	keyFile := tmConfig.PrivValidatorKeyFile()
+	stateFile := tmConfig.PrivValidatorStateFile()
+	blsFile := filepath.Join(tmConfig.RootDir, tmConfig.BaseConfig.BlsState)
+	for _, file := range []string{keyFile, stateFile, blsFile} {
+		if _, err := os.Stat(file); err != nil {
+			return abciTypes.PubKey{}, "", "", fmt.Errorf("missing validator key, run `%v validator create` first: %v",
+				app.Name, err)
+		}
+	}
+	pv := privval.LoadFilePV(keyFile, stateFile)
+	blsState := consensus.LoadFileBS(blsFile)
+	tmAddress := pv.GetPubKey().Address().String()
+	blsKeyJSON, err := json.Marshal(tmTypes.BLSPubKey{
+		Type:    "Secp256k1",
+		Address: tmAddress,
+		Value:   blsState.GetPubKPKE(),
+	})
+	if err != nil {
+		return abciTypes.PubKey{}, "", "", fmt.Errorf("failed to encode bls key: %v", err)
+	}
+	return tmTypes.TM2PB.PubKey(pv.GetPubKey()), tmAddress, string(blsKeyJSON), nil
+}
+
+// bondTxPayload is the data of the bond tx inserting the PosItem of the keys
+func bondTxPayload(pubKey abciTypes.PubKey, beneficiary common.Address, blsKeyString string) ([]byte, error) {
+	return json.Marshal(bondTxData{
+		PubKey:       pubKey,
+		Beneficiary:  beneficiary.Hex(),
+		BlsKeyString: blsKeyString,
+	})
+}
+
+func validatorKeyStore(ctx *cli.Context) *keystore.KeyStore {
+	keyStoreDir := ctx.GlobalString(ethUtils.KeyStoreDirFlag.Name)
+	if keyStoreDir == "" {
+		keyStoreDir = filepath.Join(emtUtils.MakeDataDir(ctx), "keystore")
+	}
+	return keystore.NewKeyStore(keyStoreDir, keystore.StandardScryptN, keystore.StandardScryptP)
+}
+
+func validatorCreateCmd(ctx *cli.Context) error {
+	tmConfig := loadTMConfig(ctx)
+	if err := genValidatorKeys(tmConfig); err != nil {
+		return err
+	}
+	pubKey, tmAddress, blsKeyString, err := validatorKeys(tmConfig)
+	if err != nil {
+		return err
+	}
+
+	ks := validatorKeyStore(ctx)
+	password := getPassPhrase("Your new signer account is locked with a password. Please give a password.",
+		true, 0, ethUtils.MakePasswordList(ctx))
+	signer, err := ks.NewAccount(password)
+	if err != nil {
+		ethUtils.Fatalf("Failed to create signer account: %v", err)
+	}
+
+	beneficiary := signer.Address
+	if ctx.IsSet(validatorBeneficiaryFlag.Name) {
+		beneficiary = common.HexToAddress(ctx.String(validatorBeneficiaryFlag.Name))
+	}
+	payload, err := bondTxPayload(pubKey, beneficiary, blsKeyString)
+	if err != nil {
+		return err
+	}
+	var data bytes.Buffer
+	json.Indent(&data, payload, "", "  ") // nolint: errcheck
+
+	fmt.Printf("tm address:  %v\n", tmAddress)
+	fmt.Printf("signer:      %v\n", signer.Address.Hex())
+	fmt.Printf("keystore:    %v\n", signer.URL.Path)
+	fmt.Printf("beneficiary: %v\n", beneficiary.Hex())
+	fmt.Printf("bond tx to %v with data:\n%s\n", txfilter.SendToLock.Hex(), data.String())
+	fmt.Printf("sign it with: %v validator bond --from %v --value <wei>\n", app.Name, signer.Address.Hex())
+	return nil
+}
+
+func validatorBondCmd(ctx *cli.Context) error {
+	pubKey, _, blsKeyString, err := validatorKeys(loadTMConfig(ctx))
+	if err != nil {
+		return err
+	}
+	signer := common.HexToAddress(ctx.String(validatorFromFlag.Name))
+	beneficiary := signer
+	if ctx.IsSet(validatorBeneficiaryFlag.Name) {
+		beneficiary = common.HexToAddress(ctx.String(validatorBeneficiaryFlag.Name))
+	}
+	data, err := bondTxPayload(pubKey, beneficiary, blsKeyString)
+	if err != nil {
+		return err
+	}
+	return signValidatorTx(ctx, txfilter.SendToLock, data)
+}
+
+func validatorUnbondCmd(ctx *cli.Context) error {
+	return signValidatorTx(ctx, txfilter.SendToUnlock, nil)
+}
+
+// signValidatorTx signs a tx of --from with the local keystore, prints it and submits it if asked
+func signValidatorTx(ctx *cli.Context, to common.Address, data []byte) error {
+	if !ctx.IsSet(validatorFromFlag.Name) {
+		return fmt.Errorf("--%s is required", validatorFromFlag.Name)
+	}
+	from := common.HexToAddress(ctx.String(validatorFromFlag.Name))
+	value, ok := new(big.Int).SetString(ctx.String(validatorValueFlag.Name), 10)
+	if !ok {
+		return fmt.Errorf("invalid value %v", ctx.String(validatorValueFlag.Name))
+	}
+
+	var client *ethclient.Client
+	if rpcURL := ctx.String(validatorRPCFlag.Name); rpcURL != "" {
+		var err error
+		if client, err = ethclient.Dial(rpcURL); err != nil {
+			return fmt.Errorf("failed to connect to %v: %v", rpcURL, err)
+		}
+		defer client.Close()
+	}
+	if ctx.Bool(validatorSubmitFlag.Name) && client == nil {
+		return fmt.Errorf("--%s needs --%s", validatorSubmitFlag.Name, validatorRPCFlag.Name)
+	}
+
+	nonce := ctx.Int64(validatorNonceFlag.Name)
+	if nonce < 0 {
+		if client == nil {
+			return fmt.Errorf("--%s or --%s is required", validatorNonceFlag.Name, validatorRPCFlag.Name)
+		}
+		pendingNonce, err := client.PendingNonceAt(context.Background(), from)
+		if err != nil {
+			return err
+		}
+		nonce = int64(pendingNonce)
+	}
+	chainID := big.NewInt(ctx.Int64(validatorChainIDFlag.Name))
+	if chainID.Sign() == 0 {
+		if client == nil {
+			return fmt.Errorf("--%s or --%s is required", validatorChainIDFlag.Name, validatorRPCFlag.Name)
+		}
+		var err error
+		if chainID, err = client.NetworkID(context.Background()); err != nil {
+			return err
+		}
+	}
+	gasPrice := big.NewInt(0)
+	if ctx.IsSet(validatorGasPriceFlag.Name) {
+		if _, ok := gasPrice.SetString(ctx.String(validatorGasPriceFlag.Name), 10); !ok {
+			return fmt.Errorf("invalid gas price %v", ctx.String(validatorGasPriceFlag.Name))
+		}
+	} else if client != nil {
+		var err error
+		if gasPrice, err = client.SuggestGasPrice(context.Background()); err != nil {
+			return err
+		}
+	}
+
+	tx := ethTypes.NewTransaction(uint64(nonce), to, value, ctx.Uint64(validatorGasFlag.Name), gasPrice, data)
+	ks := validatorKeyStore(ctx)
+	password := getPassPhrase(fmt.Sprintf("Unlocking signer %v", from.Hex()), false, 0, ethUtils.MakePasswordList(ctx))
+	signedTx, err := ks.SignTxWithPassphrase(accounts.Account{Address: from}, password, tx, chainID)
+	if err != nil {
+		return fmt.Errorf("failed to sign tx: %v", err)
+	}
+	rawTx, err := rlp.EncodeToBytes(signedTx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("tx hash: %v\n", signedTx.Hash().Hex())
+	fmt.Printf("raw tx:  %v\n", hexutil.Encode(rawTx))
+
+	if ctx.Bool(validatorSubmitFlag.Name) {
+		if err := client.SendTransaction(context.Background(), signedTx); err != nil {
+			return fmt.Errorf("failed to submit tx: %v", err)
+		}
+		fmt.Println("tx submitted")
+	}
+	return nil
+}
+
+func validatorStatusCmd(ctx *cli.Context) error {
+	if !ctx.IsSet(validatorFromFlag.Name) {
+		return fmt.Errorf("--%s is required", validatorFromFlag.Name)
+	}
+	url := fmt.Sprintf("%v/GetValidatorStatus?signer=%v", strings.TrimRight(ctx.String(validatorHTTPFlag.Name), "/"),
+		common.HexToAddress(ctx.String(validatorFromFlag.Name)).Hex())
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%v: %s", resp.Status, body)
+	}
+	var status interface{}
+	if err := json.Unmarshal(body, &status); err != nil {
+		return err
+	}
+	out, _ := json.MarshalIndent(status, "", "  ")
+	fmt.Println(string(out))
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+	tmcfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	tmTypes "github.com/tendermint/tendermint/types"
+)
+
+func TestBondTxPayload(t *testing.T) {
+	tmKey := ed25519.GenPrivKey().PubKey()
+	pubKey := tmTypes.TM2PB.PubKey(tmKey)
+	beneficiary := common.HexToAddress("0x00000000000000000000000000000000000000Ab")
+	blsKeyJSON, _ := json.Marshal(tmTypes.BLSPubKey{Type: "Secp256k1", Address: tmKey.Address().String()})
+
+	payload, err := bondTxPayload(pubKey, beneficiary, string(blsKeyJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the keys read by the bond tx handler of txfilter
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"pub_key", "beneficiary", "bls_key_string"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("bond payload %s has no %v", payload, key)
+		}
+	}
+	var data bondTxData
+	if err := json.Unmarshal(payload, &data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data.PubKey, pubKey) {
+		t.Errorf("pub key %v, expected %v", data.PubKey, pubKey)
+	}
+	if data.Beneficiary != beneficiary.Hex() {
+		t.Errorf("beneficiary %v, expected %v", data.Beneficiary, beneficiary.Hex())
+	}
+	var blsKey tmTypes.BLSPubKey
+	if err := json.Unmarshal([]byte(data.BlsKeyString), &blsKey); err != nil {
+		t.Fatalf("bls key %v: %v", data.BlsKeyString, err)
+	}
+	if blsKey.Address != tmKey.Address().String() {
+		t.Errorf("bls key of %v, expected %v", blsKey.Address, tmKey.Address())
+	}
+}
+
+func TestValidatorKeys(t *testing.T) {
+	home, err := ioutil.TempDir("", "dtfn-validator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	tmConfig := tmcfg.DefaultConfig()
+	tmConfig.SetRoot(home)
+
+	// bond does not generate the keys
+	if _, _, _, err := validatorKeys(tmConfig); err == nil {
+		t.Fatal("expecting an error without the keys")
+	}
+	if _, err := os.Stat(tmConfig.PrivValidatorKeyFile()); !os.IsNotExist(err) {
+		t.Fatalf("validatorKeys created %v", tmConfig.PrivValidatorKeyFile())
+	}
+
+	if err := genValidatorKeys(tmConfig); err != nil {
+		t.Fatal(err)
+	}
+	pubKey, tmAddress, blsKeyString, err := validatorKeys(tmConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pubKey == (abciTypes.PubKey{}) || tmAddress == "" {
+		t.Fatalf("empty keys %v %v", pubKey, tmAddress)
+	}
+	var blsKey tmTypes.BLSPubKey
+	if err := json.Unmarshal([]byte(blsKeyString), &blsKey); err != nil || blsKey.Address != tmAddress {
+		t.Fatalf("bls key %v of %v: %v", blsKeyString, tmAddress, err)
+	}
+
+	// the keys are kept when create runs again
+	if err := genValidatorKeys(tmConfig); err != nil {
+		t.Fatal(err)
+	}
+	if _, again, _, err := validatorKeys(tmConfig); err != nil || again != tmAddress {
+		t.Fatalf("keys of %v replaced by %v: %v", tmAddress, again, err)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/trie"
+	"gopkg.in/urfave/cli.v1"
+
+	emtUtils "github.com/DTFN/dtfn/cmd/utils"
+)
+
+var (
+	verifyFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Value: 1,
+		Usage: "first height to check",
+	}
+	verifyToFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "last height to check, defaults to the head block",
+	}
+	verifyRepairFlag = cli.BoolFlag{
+		Name:  "repair",
+		Usage: "rewrite the tx lookup entries that do not point to their block",
+	}
+
+	verifyReceiptsCommand = cli.Command{
+		Action:    verifyReceiptsCmd,
+		Name:      "verify-receipts",
+		Usage:     "check the stored receipts and logs of a height range",
+		ArgsUsage: " ",
+		Flags:     []cli.Flag{verifyFromFlag, verifyToFlag, verifyRepairFlag},
+		Description: `Checks for every canonical block that the receipts match the txs and the
+receipt root and that the tx lookup entries point to the block. The block hash
+of the receipts and logs is not stored, it is derived from the block on read.
+The node must be stopped, the chain db is opened directly. With --repair the tx
+lookup entries are rewritten, a receipt mismatch can only be reported.`,
+	}
+)
+
+// receiptProblem is an inconsistency found at one height
+type receiptProblem struct {
+	height  uint64
+	message string
+	fixable bool
+}
+
+func verifyReceiptsCmd(ctx *cli.Context) error {
+	chainDb, err := rawdb.NewLevelDBDatabase(filepath.Join(emtUtils.MakeDataDir(ctx),
+		"gelchain/chaindata"), 0, 0, "")
+	if err != nil {
+		return fmt.Errorf("could not open database: %v", err)
+	}
+	defer chainDb.Close()
+
+	from := ctx.Uint64(verifyFromFlag.Name)
+	to := ctx.Uint64(verifyToFlag.Name)
+	if to == 0 {
+		headHash := rawdb.ReadHeadBlockHash(chainDb)
+		head := rawdb.ReadHeaderNumber(chainDb, headHash)
+		if head == nil {
+			return fmt.Errorf("no head block in the database")
+		}
+		to = *head
+	}
+	if from > to {
+		return fmt.Errorf("--from %v is above --to %v", from, to)
+	}
+	repair := ctx.Bool(verifyRepairFlag.Name)
+
+	var total, fixed, unfixable int
+	for height := from; height <= to; height++ {
+		problems, repaired := verifyReceiptsAt(chainDb, height, repair)
+		for _, problem := range problems {
+			fmt.Printf("height %v: %v\n", problem.height, problem.message)
+			if !problem.fixable {
+				unfixable++
+			}
+		}
+		total += len(problems)
+		if repaired {
+			fixed++
+			fmt.Printf("height %v: repaired\n", height)
+		}
+	}
+	fmt.Printf("checked heights %v-%v: %v problems, %v heights repaired, %v problems not fixable\n",
+		from, to, total, fixed, unfixable)
+	if unfixable != 0 || (total != 0 && !repair) {
+		return fmt.Errorf("found %v inconsistent receipts", total)
+	}
+	return nil
+}
+
+// verifyReceiptsAt checks the canonical block at height and repairs it if asked and possible
+func verifyReceiptsAt(db ethdb.Database, height uint64, repair bool) ([]receiptProblem, bool) {
+	var problems []receiptProblem
+	report := func(fixable bool, format string, args ...interface{}) {
+		problems = append(problems, receiptProblem{height, fmt.Sprintf(format, args...), fixable})
+	}
+
+	hash := rawdb.ReadCanonicalHash(db, height)
+	if (hash == common.Hash{}) {
+		report(false, "no canonical block")
+		return problems, false
+	}
+	block := rawdb.ReadBlock(db, hash, height)
+	if block == nil {
+		report(false, "block %v has no body", hash.Hex())
+		return problems, false
+	}
+	txs := block.Transactions()
+	receipts := rawdb.ReadRawReceipts(db, hash, height)
+	if len(receipts) != len(txs) {
+		report(false, "%v receipts for %v txs", len(receipts), len(txs))
+		return problems, false
+	}
+	if root := ethTypes.DeriveSha(receipts, new(trie.Trie)); root != block.ReceiptHash() {
+		report(false, "receipt root %v differs from the header one %v", root.Hex(), block.ReceiptHash().Hex())
+	}
+
+	rewriteLookups := false
+	for i, tx := range txs {
+		_, blockHash, blockNumber, index := rawdb.ReadTransaction(db, tx.Hash())
+		if blockHash != hash || blockNumber != height || index != uint64(i) {
+			report(true, "tx %v lookup points to block %v height %v index %v", tx.Hash().Hex(), blockHash.Hex(), blockNumber, index)
+			rewriteLookups = true
+		}
+	}
+
+	if !repair || !rewriteLookups {
+		return problems, false
+	}
+	rawdb.WriteTxLookupEntries(db, block)
+	return problems, true
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestVerifyReceiptsLookup(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	to := common.HexToAddress("0x01")
+	txs := []*ethTypes.Transaction{
+		ethTypes.NewTransaction(0, to, big.NewInt(1), 21000, big.NewInt(0), nil),
+		ethTypes.NewTransaction(1, to, big.NewInt(1), 21000, big.NewInt(0), nil),
+	}
+	receipts := []*ethTypes.Receipt{
+		{Status: ethTypes.ReceiptStatusSuccessful, CumulativeGasUsed: 21000, Logs: []*ethTypes.Log{}},
+		{Status: ethTypes.ReceiptStatusSuccessful, CumulativeGasUsed: 42000, Logs: []*ethTypes.Log{}},
+	}
+	block := ethTypes.NewBlock(&ethTypes.Header{Number: big.NewInt(1)}, txs, nil, receipts, new(trie.Trie))
+	rawdb.WriteBlock(db, block)
+	rawdb.WriteCanonicalHash(db, block.Hash(), 1)
+	rawdb.WriteReceipts(db, block.Hash(), 1, receipts)
+	rawdb.WriteTxLookupEntries(db, block)
+
+	if problems, repaired := verifyReceiptsAt(db, 1, false); len(problems) != 0 || repaired {
+		t.Fatalf("consistent block reported %v, repaired %v", problems, repaired)
+	}
+
+	// the lookup of the second tx points to a block that is not canonical
+	other := ethTypes.NewBlock(&ethTypes.Header{Number: big.NewInt(2)}, txs[1:], nil, receipts[:1], new(trie.Trie))
+	rawdb.WriteTxLookupEntries(db, other)
+	problems, repaired := verifyReceiptsAt(db, 1, false)
+	if len(problems) != 1 || !problems[0].fixable || repaired {
+		t.Fatalf("bad lookup reported %v, repaired %v", problems, repaired)
+	}
+	if _, blockHash, _, _ := rawdb.ReadTransaction(db, txs[1].Hash()); blockHash == block.Hash() {
+		t.Fatalf("the lookup was rewritten without --repair")
+	}
+
+	problems, repaired = verifyReceiptsAt(db, 1, true)
+	if len(problems) != 1 || !repaired {
+		t.Fatalf("repair reported %v, repaired %v", problems, repaired)
+	}
+	if problems, repaired = verifyReceiptsAt(db, 1, false); len(problems) != 0 || repaired {
+		t.Fatalf("repaired block reported %v, repaired %v", problems, repaired)
+	}
+
+	// a missing receipt can not be repaired
+	rawdb.WriteReceipts(db, block.Hash(), 1, receipts[:1])
+	problems, repaired = verifyReceiptsAt(db, 1, true)
+	if len(problems) != 1 || problems[0].fixable || repaired {
+		t.Fatalf("missing receipt reported %v, repaired %v", problems, repaired)
+	}
+}
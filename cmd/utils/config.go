@@ -81,8 +81,8 @@ func DefaultNodeConfig() node.Config {
 	cfg := node.DefaultConfig
 	cfg.Name = clientIdentifier
 	cfg.Version = params.Version
-	cfg.HTTPModules = append(cfg.HTTPModules, "eth")
-	cfg.WSModules = append(cfg.WSModules, "eth")
+	cfg.HTTPModules = append(cfg.HTTPModules, "eth", "dtfn")
+	cfg.WSModules = append(cfg.WSModules, "eth", "dtfn")
 	cfg.IPCPath = "geth.ipc"
 
 	emHome := os.Getenv(emHome)
@@ -98,6 +98,9 @@ func DefaultNodeConfig() node.Config {
 func SetEthermintNodeConfig(cfg *node.Config) {
 	cfg.P2P.MaxPeers = 0
 	cfg.P2P.NoDiscovery = true
+	// the p2p server is stopped right after its start, tendermint does the networking.
+	// Without a listen address several nodes can run on one host.
+	cfg.P2P.ListenAddr = ""
 }
 
 // SetEthermintEthConfig takes a ethereum configuration and applies dtfn specific configuration
@@ -38,6 +38,14 @@ var (
 		Usage: "socket | grpc",
 	}
 
+	// HttpServerAddrFlag is the address of the dtfn http server
+	// #unstable
+	HttpServerAddrFlag = cli.StringFlag{
+		Name:  "http_laddr",
+		Value: ":19190",
+		Usage: "This is the address that the gelchain http server will listen on.",
+	}
+
 	// VerbosityFlag defines the verbosity of the logging
 	// #unstable
 	VerbosityFlag = cli.IntFlag{
@@ -65,7 +73,7 @@ var (
 	// #unstable
 	TargetGasLimitFlag = cli.Uint64Flag{
 		Name:  "target_gas_limit",
-		Usage: "Target gas limit sets the artificial target gas floor for the blocks to mine",
+		Usage: "Deprecated: the block gas limit is a consensus parameter, set blockgaslimit in the version config or the genesis gasLimit",
 		Value: GenesisTargetGasLimit.Uint64(),
 	}
 
@@ -143,6 +151,12 @@ var (
 		Usage: "This is the address that tendermint will use to connect other tendermint port.",
 	}
 
+	TendermintRPCListenAddress = cli.StringFlag{
+		Name:  "tendermint_rpcaddr",
+		Value: "tcp://127.0.0.1:26657",
+		Usage: "This is the address that the tendermint rpc server will listen on.",
+	}
+
 	TendermintP2PExternalAddress = cli.StringFlag{
 		Name:  "tm_external_addr",
 		Value: "",
@@ -244,6 +258,18 @@ var (
 		Usage: "the threshold of ethereum txpool for remote broadcasted tx",
 	}
 
+	MinGasPrice = cli.Int64Flag{
+		Name:  "min_gas_price",
+		Value: 0,
+		Usage: "the minimum gas price this node accepts into its mempool, in wei",
+	}
+
+	TxIndexKeys = cli.StringFlag{
+		Name:  "tx_index_keys",
+		Value: "eth.hash,eth.from,eth.to,eth.relayer,log.address,log.topic0,log.topic1,log.topic2,log.topic3",
+		Usage: "comma separated list of DeliverTx event keys tendermint indexes for tx_search",
+	}
+
 	RollbackHeight = cli.Int64Flag{
 		Name:  "rollback_height",
 		Value: 200,
@@ -331,4 +357,33 @@ var (
 		Value: 64,
 		Usage: "the size of the block cache",
 	}
+
+	HealthCommitAgeFlag = cli.IntFlag{
+		Name:  "health_commit_age",
+		Usage: "seconds the latest block may be old before /readyz fails, 0 derives it from the empty blocks interval and skips the check without empty blocks",
+	}
+
+	HealthCommitTimeoutFlag = cli.IntFlag{
+		Name:  "health_commit_timeout",
+		Value: 60,
+		Usage: "seconds an eth state commit may run before /healthz reports it stuck",
+	}
+
+	HealthTxpoolBacklogFlag = cli.IntFlag{
+		Name:  "health_txpool_backlog",
+		Value: 1024,
+		Usage: "unread txpool chain head events above which /readyz fails",
+	}
+
+	TxStatusRetentionFlag = cli.IntFlag{
+		Name:  "tx_status_retention",
+		Value: 600,
+		Usage: "seconds the stages of a tx are kept for dtfn_getTransactionStatus after its last one",
+	}
+
+	ShutdownTimeoutFlag = cli.IntFlag{
+		Name:  "shutdown_timeout",
+		Value: 60,
+		Usage: "seconds the graceful shutdown may take before the process exits anyway",
+	}
 )
@@ -2,7 +2,6 @@ package utils
 
 import (
 	"os"
-	"os/signal"
 	"os/user"
 	"path/filepath"
 	"runtime"
@@ -15,25 +14,11 @@ import (
 	"github.com/DTFN/dtfn/ethereum"
 )
 
-// StartNode will start up the node.
+// StartNode will start up the node, the signals are handled by the caller.
 func StartNode(stack *ethereum.Node) {
 	if err := stack.Start(); err != nil {
 		ethUtils.Fatalf("Error starting protocol stack: %v", err)
 	}
-	go func() {
-		sigc := make(chan os.Signal, 1)
-		signal.Notify(sigc, os.Interrupt)
-		defer signal.Stop(sigc)
-		<-sigc
-		log.Info("Got interrupt, shutting down...")
-		go stack.Close() // nolint: errcheck
-		for i := 10; i > 0; i-- {
-			<-sigc
-			if i > 1 {
-				log.Warn("Already shutting down, interrupt more to panic.", "times", i-1)
-			}
-		}
-	}()
 }
 
 // HomeDir returns the user's home most likely home directory
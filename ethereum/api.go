@@ -1,35 +1,87 @@
 package ethereum
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/txfilter"
+	"github.com/ethereum/go-ethereum/rpc"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
 )
 
+var (
+	// ErrNoTendermintNode is the sync status error of a node with neither an in-process tendermint nor a tendermint rpc
+	ErrNoTendermintNode = errors.New("neither an in-process tendermint nor a tendermint rpc")
+	errNoStrategy       = errors.New("the app is not started")
+)
+
+// PosTableResult is a PosTable as returned by the dtfn rpc
+type PosTableResult struct {
+	PosItemMap map[common.Address]*txfilter.PosItem `json:"posItemMap"`
+	Threshold  *hexutil.Big                         `json:"threshold"`
+	TotalSlots int64                                `json:"totalSlots"`
+}
+
+// newPosTableResult copies posTable under its lock, the app keeps changing the table while the result is encoded
+func newPosTableResult(posTable *txfilter.PosTable) *PosTableResult {
+	posTable = posTable.Copy()
+	return &PosTableResult{
+		PosItemMap: posTable.PosItemMap,
+		Threshold:  (*hexutil.Big)(posTable.Threshold),
+		TotalSlots: posTable.TotalSlots,
+	}
+}
+
+// ValidatorResult is a validator of the last height as returned by the dtfn rpc
+type ValidatorResult struct {
+	TmAddress   string           `json:"tmAddress"`
+	PubKey      abciTypes.PubKey `json:"pubKey"`
+	Power       int64            `json:"power"`
+	Signer      common.Address   `json:"signer"`
+	Beneficiary common.Address   `json:"beneficiary"`
+}
+
+// EpochInfo is the epoch of the last height as returned by the dtfn rpc
+type EpochInfo struct {
+	Height          int64        `json:"height"`
+	Epoch           int64        `json:"epoch"`
+	EpochBlocks     int64        `json:"epochBlocks"`
+	NextEpochHeight int64        `json:"nextEpochHeight"`
+	TotalBalance    *hexutil.Big `json:"totalBalance"`
+	SelectCount     int          `json:"selectCount"`
+	DKGMembersLimit int          `json:"dkgMembersLimit"`
+}
+
 // We must implement our own net service since we don't have access to `internal/ethapi`
 
 // NetRPCService mirrors the implementation of `internal/ethapi`
+// The geth p2p server is stopped, the peers are the tendermint ones.
 // #unstable
 type NetRPCService struct {
 	networkVersion uint64
+	backend        *Backend
 }
 
 // NewNetRPCService creates a new net API instance.
 // #unstable
-func NewNetRPCService(networkVersion uint64) *NetRPCService {
-	return &NetRPCService{networkVersion}
+func NewNetRPCService(networkVersion uint64, backend *Backend) *NetRPCService {
+	return &NetRPCService{networkVersion, backend}
 }
 
 // Listening returns an indication if the node is listening for network connections.
 // #unstable
 func (n *NetRPCService) Listening() bool {
-	return true // always listening
+	return n.backend.IsListening()
 }
 
-// PeerCount returns the number of connected peers
+// PeerCount returns the number of connected tendermint peers
 // #unstable
 func (n *NetRPCService) PeerCount() hexutil.Uint {
-	return hexutil.Uint(0)
+	return hexutil.Uint(n.backend.PeerCount())
 }
 
 // Version returns the current ethereum protocol version.
@@ -37,3 +89,164 @@ func (n *NetRPCService) PeerCount() hexutil.Uint {
 func (n *NetRPCService) Version() string {
 	return fmt.Sprintf("%d", n.networkVersion)
 }
+
+// DtfnRPCService offers the dtfn specific rpc methods, available in the `dtfn` namespace
+// #unstable
+type DtfnRPCService struct {
+	backend *Backend
+}
+
+// NewDtfnRPCService creates a new dtfn API instance.
+// #unstable
+func NewDtfnRPCService(backend *Backend) *DtfnRPCService {
+	return &DtfnRPCService{backend}
+}
+
+// SyncStatus returns the tendermint sync status of the node
+// #unstable
+func (d *DtfnRPCService) SyncStatus() (*SyncStatus, error) {
+	return d.backend.SyncStatus()
+}
+
+// GetPosTable returns the PosTable of the current epoch
+// #unstable
+func (d *DtfnRPCService) GetPosTable() (*PosTableResult, error) {
+	strategy := d.backend.Strategy()
+	if strategy == nil {
+		return nil, errNoStrategy
+	}
+	return newPosTableResult(strategy.CurrEpochValData.PosTable), nil
+}
+
+// GetNextPosTable returns the PosTable the next epoch starts with
+// #unstable
+func (d *DtfnRPCService) GetNextPosTable() (*PosTableResult, error) {
+	strategy := d.backend.Strategy()
+	if strategy == nil {
+		return nil, errNoStrategy
+	}
+	return newPosTableResult(strategy.NextEpochValData.PosTable), nil
+}
+
+// GetCurrentValidators returns the validators of the last height
+// #unstable
+func (d *DtfnRPCService) GetCurrentValidators() ([]*ValidatorResult, error) {
+	strategy := d.backend.Strategy()
+	if strategy == nil {
+		return nil, errNoStrategy
+	}
+	posTable := strategy.CopyCurrEpochValData().PosTable
+	heightValData := strategy.CopyCurrentHeightValData()
+	validators := make([]*ValidatorResult, 0, len(heightValData.Validators))
+	for tmAddress, v := range heightValData.Validators {
+		validator := &ValidatorResult{
+			TmAddress: tmAddress,
+			PubKey:    v.PubKey,
+			Power:     v.Power,
+			Signer:    v.Signer,
+		}
+		if posItem, ok := posTable.PosItemMap[v.Signer]; ok {
+			validator.Beneficiary = posItem.Beneficiary
+		}
+		validators = append(validators, validator)
+	}
+	sort.Slice(validators, func(i, j int) bool {
+		return validators[i].TmAddress < validators[j].TmAddress
+	})
+	return validators, nil
+}
+
+// GetEpochInfo returns the position of the last height in its epoch
+// #unstable
+func (d *DtfnRPCService) GetEpochInfo() (*EpochInfo, error) {
+	strategy := d.backend.Strategy()
+	if strategy == nil {
+		return nil, errNoStrategy
+	}
+	height := strategy.CopyCurrentHeightValData().Height
+	currEpoch := strategy.CopyCurrEpochValData()
+	epoch := height / txfilter.EpochBlocks
+	return &EpochInfo{
+		Height:          height,
+		Epoch:           epoch,
+		EpochBlocks:     txfilter.EpochBlocks,
+		NextEpochHeight: (epoch + 1) * txfilter.EpochBlocks,
+		TotalBalance:    (*hexutil.Big)(currEpoch.TotalBalance),
+		SelectCount:     currEpoch.SelectCount,
+		DKGMembersLimit: currEpoch.DKGMembersLimit,
+	}, nil
+}
+
+// SendRawTransactions sends a batch of signed rlp encoded txs to the tendermint mempool,
+// keeping the nonce order of each sender. The results are in the order of the txs.
+// #unstable
+func (d *DtfnRPCService) SendRawTransactions(encodedTxs []hexutil.Bytes) ([]*SendTxResult, error) {
+	return d.backend.BroadcastTxs(encodedTxs)
+}
+
+// GetTransactionStatus returns the stages a tx went through on this node, nil if the tx is unknown
+// or its last stage is older than the retention window
+// #unstable
+func (d *DtfnRPCService) GetTransactionStatus(hash common.Hash) *TxStatus {
+	status, _ := d.backend.TxTracker().Status(hash)
+	return status
+}
+
+// GetTransactionByHash returns the committed tx with the given ethereum or tendermint hash,
+// nil if the tx is unknown
+// #unstable
+func (d *DtfnRPCService) GetTransactionByHash(hash common.Hash) *TxLookup {
+	lookup, _ := d.backend.LookupTransaction(hash)
+	return lookup
+}
+
+// GetAuthTable returns a copy of the auth table, taken under its lock
+// #unstable
+func (d *DtfnRPCService) GetAuthTable() *txfilter.AuthTable {
+	return txfilter.EthAuthTable.Copy()
+}
+
+// EthRPCService overrides the `eth` methods go-ethereum answers from its txpool or its downloader.
+// The txs sent to tendermint leave the txpool, the pending state is the check tx state of the app.
+// The blocks are synced by tendermint, the downloader is unused.
+// #unstable
+type EthRPCService struct {
+	backend *Backend
+}
+
+// NewEthRPCService creates a new eth API instance.
+// #unstable
+func NewEthRPCService(backend *Backend) *EthRPCService {
+	return &EthRPCService{backend}
+}
+
+// Syncing returns false once the node caught up, the tendermint block sync progress otherwise
+// #unstable
+func (e *EthRPCService) Syncing() (interface{}, error) {
+	progress, err := e.backend.SyncProgress()
+	if err != nil {
+		return nil, err
+	}
+	if progress == nil {
+		return false, nil
+	}
+	return progress, nil
+}
+
+// GetTransactionCount returns the nonce of address at the given block,
+// the pending one accounts for the txs in the tendermint mempool.
+// #unstable
+func (e *EthRPCService) GetTransactionCount(ctx context.Context, address common.Address,
+	blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Uint64, error) {
+	if blockNr, ok := blockNrOrHash.Number(); ok && blockNr == rpc.PendingBlockNumber {
+		_, pendingState := e.backend.Es().Pending()
+		nonce := pendingState.GetNonce(address)
+		return (*hexutil.Uint64)(&nonce), nil
+	}
+	state, _, err := e.backend.Ethereum().APIBackend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	nonce := state.GetNonce(address)
+	return (*hexutil.Uint64)(&nonce), state.Error()
+}
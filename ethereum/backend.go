@@ -1,12 +1,17 @@
 package ethereum
 
 import (
+	"fmt"
+	"math/big"
+	"sync"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	ethTypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth"
-	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/rpc"
 
@@ -17,6 +22,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	emtTypes "github.com/DTFN/dtfn/types"
 	mempl "github.com/tendermint/tendermint/mempool"
+	tmNode "github.com/tendermint/tendermint/node"
 )
 
 //----------------------------------------------------------------------
@@ -30,9 +36,6 @@ type Backend struct {
 	ethereum  *eth.Ethereum
 	ethConfig *eth.Config
 
-	// txBroadcastLoop subscription
-	txSub *event.TypeMuxSubscription
-
 	// EthState
 	es *EthState
 
@@ -40,9 +43,28 @@ type Backend struct {
 	client rpcClient.HTTPClient
 
 	//leilei add.  Use mempool to forward txs directly
-	memPool       mempl.Mempool
-	currentTxInfo ethTypes.TxInfo
-	cachedTxInfo  map[common.Hash]ethTypes.TxInfo
+	memPool      mempl.Mempool
+	cachedTxInfo map[common.Hash]ethTypes.TxInfo
+
+	// forwards the txs of the txpool to the tendermint mempool
+	broadcaster *Broadcaster
+
+	// in-process tendermint node, nil with an external tendermint
+	tmNode *tmNode.Node
+	// tendermint block height when the node started
+	startingBlock int64
+
+	// strategy of the app, read by the dtfn rpc
+	strategy *emtTypes.Strategy
+
+	healthConfig HealthConfig
+
+	// stages of the txs by eth hash, read by the tx status rpc
+	txTracker *TxTracker
+
+	// set atomically once the node shuts down, the rpc refuses the txs
+	txIntakeStopped int32
+	stopOnce        sync.Once
 }
 
 // NewBackend creates a new Backend
@@ -77,7 +99,10 @@ func NewBackend(node *Node, ethConfig *eth.Config,
 		es:           es,
 		client:       client,
 		cachedTxInfo: make(map[common.Hash]ethTypes.TxInfo),
+		healthConfig: DefaultHealthConfig(),
+		txTracker:    NewTxTracker(DefaultTxStatusRetention),
 	}
+	ethBackend.broadcaster = NewBroadcaster(ethBackend)
 	return ethBackend, nil
 }
 
@@ -101,12 +126,27 @@ func (b *Backend) SetMemPool(memPool mempl.Mempool) {
 	b.memPool = memPool
 }
 
+// SetStrategy sets the strategy of the app
+func (b *Backend) SetStrategy(strategy *emtTypes.Strategy) {
+	b.strategy = strategy
+}
+
+func (b *Backend) Strategy() *emtTypes.Strategy {
+	return b.strategy
+}
+
 func (b *Backend) MemPool() mempl.Mempool {
 	return b.memPool
 }
 
-func (b *Backend) CurrentTxInfo() ethTypes.TxInfo {
-	return b.currentTxInfo
+// LocalTxInfo returns the TxInfo of a tx sent through the rpc while tendermint runs its local CheckTx
+func (b *Backend) LocalTxInfo(txHash common.Hash) (ethTypes.TxInfo, bool) {
+	return b.broadcaster.TxInfo(txHash)
+}
+
+// TxTracker returns the tracker of the tx stages
+func (b *Backend) TxTracker() *TxTracker {
+	return b.txTracker
 }
 
 func (b *Backend) CachedTxInfo() map[common.Hash]ethTypes.TxInfo {
@@ -141,6 +181,55 @@ func (b *Backend) InsertCachedTxInfo(txHash common.Hash, txInfo ethTypes.TxInfo)
 	b.cachedTxInfo[txHash] = txInfo
 }
 
+// RecentRewards returns the rewards paid to beneficiary in the last blocks committed by this node
+// #unstable
+func (b *Backend) RecentRewards(beneficiary common.Address) *RecentRewards {
+	return b.es.RecentRewards(beneficiary)
+}
+
+// IndexTxHash maps the tendermint hash of a delivered tx to its ethereum hash
+// #unstable
+func (b *Backend) IndexTxHash(tmHash []byte, ethHash common.Hash) {
+	b.es.AddTxHashMapping(tmHash, ethHash)
+}
+
+// RecordSlashes adds the slashes of the block in execution to the slashing journal
+// #unstable
+func (b *Backend) RecordSlashes(records []*emtTypes.SlashRecord) {
+	b.es.AddSlashRecords(records)
+}
+
+// SlashRecords returns the committed slashing journal entries between two heights included
+// #unstable
+func (b *Backend) SlashRecords(fromHeight, toHeight int64) ([]*emtTypes.SlashRecord, error) {
+	return ReadSlashRecords(b.ethereum.ChainDb(), fromHeight, toHeight)
+}
+
+// LookupTransaction finds a committed tx by its ethereum or its tendermint hash
+// #unstable
+func (b *Backend) LookupTransaction(hash common.Hash) (*TxLookup, error) {
+	db := b.ethereum.ChainDb()
+	ethHash := hash
+	tmHash, found := ReadTmTxHash(db, hash)
+	if !found {
+		if mapped, ok := ReadEthTxHash(db, hash.Bytes()); ok {
+			ethHash, tmHash = mapped, hash.Bytes()
+		}
+	}
+	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(db, ethHash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %X not found", hash)
+	}
+	return &TxLookup{
+		EthHash:     ethHash,
+		TmHash:      tmHash,
+		BlockHash:   blockHash,
+		BlockNumber: hexutil.Uint64(blockNumber),
+		Index:       hexutil.Uint64(index),
+		Tx:          tx,
+	}, nil
+}
+
 // Commit finalises the current block
 // #unstable
 func (b *Backend) Commit() (common.Hash, error) {
@@ -174,6 +263,12 @@ func (b *Backend) GasLimit() uint64 {
 	return b.es.GasLimit()
 }
 
+// BaseFee returns the base fee of the block in execution
+// #unstable
+func (b *Backend) BaseFee() *big.Int {
+	return b.es.BaseFee()
+}
+
 //----------------------------------------------------------------------
 // Implements: node.Service
 
@@ -184,7 +279,7 @@ func (b *Backend) APIs() []rpc.API {
 	retApis := []rpc.API{}
 	for _, v := range apis {
 		if v.Namespace == "net" {
-			v.Service = NewNetRPCService(b.ethConfig.NetworkId)
+			v.Service = NewNetRPCService(b.ethConfig.NetworkId, b)
 		}
 		if v.Namespace == "miner" {
 			continue
@@ -194,6 +289,18 @@ func (b *Backend) APIs() []rpc.API {
 		}
 		retApis = append(retApis, v)
 	}
+	retApis = append(retApis, rpc.API{
+		Namespace: "dtfn",
+		Version:   "1.0",
+		Service:   NewDtfnRPCService(b),
+		Public:    true,
+	}, rpc.API{
+		// registered after the go-ethereum `eth` apis, its methods replace theirs
+		Namespace: "eth",
+		Version:   "1.0",
+		Service:   NewEthRPCService(b),
+		Public:    true,
+	})
 	return retApis
 }
 
@@ -201,16 +308,19 @@ func (b *Backend) APIs() []rpc.API {
 // Ethereum protocol implementation.
 // #stable
 func (b *Backend) Start(_ *p2p.Server) error {
-	go b.txBroadcastLoop()
+	b.broadcaster.Start()
 	return nil
 }
 
 // Stop implements node.Service, terminating all internal goroutines used by the
-// Ethereum protocol.
+// Ethereum protocol. The blockchain writes its trie cache to the chain db unless
+// the node is an archive one, then the chain db is closed. Stop may be called more than once.
 // #stable
 func (b *Backend) Stop() error {
-	b.txSub.Unsubscribe()
-	b.ethereum.Stop() // nolint: errcheck
+	b.stopOnce.Do(func() {
+		b.broadcaster.Stop()
+		b.ethereum.Stop() // nolint: errcheck
+	})
 	return nil
 }
 
@@ -240,6 +350,16 @@ func (v *NullBlockProcessor) ValidateState(block, parent *ethTypes.Block, state
 	return nil
 }
 
+// TxLookup is a committed tx together with both of its hashes
+type TxLookup struct {
+	EthHash     common.Hash           `json:"ethHash"`
+	TmHash      hexutil.Bytes         `json:"tmHash"`
+	BlockHash   common.Hash           `json:"blockHash"`
+	BlockNumber hexutil.Uint64        `json:"blockNumber"`
+	Index       hexutil.Uint64        `json:"transactionIndex"`
+	Tx          *ethTypes.Transaction `json:"tx"`
+}
+
 type TxFrom struct {
 	TxHash common.Hash
 	From   common.Address
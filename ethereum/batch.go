@@ -0,0 +1,136 @@
+package ethereum
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+	mempl "github.com/tendermint/tendermint/mempool"
+	tmTypes "github.com/tendermint/tendermint/types"
+)
+
+// maxBatchTxs is the number of txs accepted by one dtfn_sendRawTransactions call
+const maxBatchTxs = 5000
+
+var errNoMempool = errors.New("tendermint mempool is not available")
+
+// SendTxResult is the outcome of one tx of a batch
+type SendTxResult struct {
+	Hash  common.Hash `json:"hash"`
+	Error string      `json:"error,omitempty"`
+}
+
+type batchTx struct {
+	index   int
+	tx      *ethTypes.Transaction
+	txBytes []byte
+	sender  common.Address
+	resCh   chan *abciTypes.Response
+}
+
+// BroadcastTxs decodes the rlp encoded txs and sends them to the tendermint mempool in one go,
+// they are checked like the txs gossiped by the peers. The txs of a sender are sent in nonce order,
+// the failure of one is reported by the following ones of the same sender. The results are in the order of encodedTxs.
+func (b *Backend) BroadcastTxs(encodedTxs []hexutil.Bytes) ([]*SendTxResult, error) {
+	if len(encodedTxs) > maxBatchTxs {
+		return nil, fmt.Errorf("batch of %v txs is over the limit of %v", len(encodedTxs), maxBatchTxs)
+	}
+	if err := b.acceptTxs(); err != nil {
+		return nil, err
+	}
+	if b.memPool == nil {
+		return nil, errNoMempool
+	}
+	// the app checks the txs with the signer of its strategy
+	var signer ethTypes.Signer = ethTypes.NewEIP155Signer(b.ethereum.BlockChain().Config().ChainID)
+	if b.strategy != nil {
+		signer = b.strategy.Signer()
+	}
+	results := make([]*SendTxResult, len(encodedTxs))
+	batch := make([]*batchTx, 0, len(encodedTxs))
+	for i, encodedTx := range encodedTxs {
+		results[i] = &SendTxResult{}
+		tx := new(ethTypes.Transaction)
+		if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Hash = tx.Hash()
+		var txSigner ethTypes.Signer = ethTypes.HomesteadSigner{}
+		if tx.Protected() {
+			txSigner = signer
+		}
+		sender, err := ethTypes.Sender(txSigner, tx)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		batch = append(batch, &batchTx{index: i, tx: tx, txBytes: encodedTx, sender: sender})
+	}
+
+	ordered := orderBatch(batch)
+
+	// the local abci client runs CheckTx in order, the callbacks are awaited afterwards
+	for _, btx := range ordered {
+		resCh := make(chan *abciTypes.Response, 1)
+		err := b.memPool.CheckTx(tmTypes.Tx(btx.txBytes), func(res *abciTypes.Response) {
+			resCh <- res
+		}, mempl.TxInfo{})
+		if err != nil {
+			broadcastFailedCounter.Inc(1)
+			b.txTracker.Record(btx.tx.Hash(), TxStageCheckTxFailed, err.Error(), 0)
+			results[btx.index].Error = err.Error()
+			continue
+		}
+		btx.resCh = resCh
+	}
+	failed := make(map[common.Address]bool)
+	for _, btx := range ordered {
+		if btx.resCh == nil {
+			failed[btx.sender] = true
+			continue
+		}
+		res := (<-btx.resCh).GetCheckTx()
+		switch {
+		case res.Code == abciTypes.CodeTypeOK:
+			broadcastOkCounter.Inc(1)
+		case failed[btx.sender]:
+			// it was checked against a state missing an earlier tx of the sender
+			broadcastFailedCounter.Inc(1)
+			results[btx.index].Error = fmt.Sprintf("an earlier tx of the sender in the batch failed, CheckTx fail. code %v: %v",
+				res.Code, res.Log)
+		default:
+			broadcastFailedCounter.Inc(1)
+			failed[btx.sender] = true
+			results[btx.index].Error = fmt.Sprintf("CheckTx fail. code %v: %v", res.Code, res.Log)
+		}
+	}
+	return results, nil
+}
+
+// orderBatch keeps the order of the batch between senders and sorts the txs of each sender by nonce
+func orderBatch(batch []*batchTx) []*batchTx {
+	slots := make(map[common.Address][]int)
+	for i, btx := range batch {
+		slots[btx.sender] = append(slots[btx.sender], i)
+	}
+	ordered := make([]*batchTx, len(batch))
+	for _, indexes := range slots {
+		txs := make([]*batchTx, len(indexes))
+		for i, index := range indexes {
+			txs[i] = batch[index]
+		}
+		sort.SliceStable(txs, func(i, j int) bool {
+			return txs[i].tx.Nonce() < txs[j].tx.Nonce()
+		})
+		for i, index := range indexes {
+			ordered[index] = txs[i]
+		}
+	}
+	return ordered
+}
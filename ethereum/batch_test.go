@@ -0,0 +1,40 @@
+package ethereum
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderBatch(t *testing.T) {
+	alice := common.HexToAddress("0x01")
+	bob := common.HexToAddress("0x02")
+	newBatchTx := func(sender common.Address, nonce uint64) *batchTx {
+		return &batchTx{
+			tx:     ethTypes.NewTransaction(nonce, common.Address{}, big.NewInt(0), 21000, big.NewInt(0), nil),
+			sender: sender,
+		}
+	}
+	batch := []*batchTx{
+		newBatchTx(alice, 2),
+		newBatchTx(bob, 7),
+		newBatchTx(alice, 0),
+		newBatchTx(bob, 5),
+		newBatchTx(alice, 1),
+	}
+
+	ordered := orderBatch(batch)
+	// each sender keeps its slots, filled in nonce order
+	expected := []struct {
+		sender common.Address
+		nonce  uint64
+	}{{alice, 0}, {bob, 5}, {alice, 1}, {bob, 7}, {alice, 2}}
+	assert.Len(t, ordered, len(expected))
+	for i, e := range expected {
+		assert.Equal(t, e.sender, ordered[i].sender)
+		assert.Equal(t, e.nonce, ordered[i].tx.Nonce())
+	}
+}
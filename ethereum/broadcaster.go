@@ -0,0 +1,228 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	amino "github.com/tendermint/go-amino"
+	mempl "github.com/tendermint/tendermint/mempool"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpcClient "github.com/tendermint/tendermint/rpc/lib/client"
+)
+
+const (
+	// broadcastRetries is how many times a tx is sent again while the tendermint mempool is full
+	broadcastRetries = 5
+	// broadcastRetryDelay is the delay before the first retry, it doubles with each one
+	broadcastRetryDelay = 200 * time.Millisecond
+	// waitForServerDelay is the delay between two probes of the tendermint rpc
+	waitForServerDelay = 3 * time.Second
+)
+
+var errBroadcasterStopped = errors.New("tx broadcaster stopped")
+
+var (
+	broadcastOkCounter          = metrics.NewRegisteredCounter("dtfn/broadcast/ok", nil)
+	broadcastFailedCounter      = metrics.NewRegisteredCounter("dtfn/broadcast/failed", nil)
+	broadcastMempoolFullCounter = metrics.NewRegisteredCounter("dtfn/broadcast/mempoolfull", nil)
+	broadcastRetryCounter       = metrics.NewRegisteredCounter("dtfn/broadcast/retry", nil)
+	broadcastStoppedCounter     = metrics.NewRegisteredCounter("dtfn/broadcast/stopped", nil)
+)
+
+var (
+	// aminoRegistered are the codecs the tendermint rpc types are registered on, a second registration panics
+	aminoRegistered    = make(map[*amino.Codec]bool)
+	aminoRegisteredMtx sync.Mutex
+)
+
+// registerAmino registers the tendermint rpc types on cdc unless it was done already
+func registerAmino(cdc *amino.Codec) {
+	aminoRegisteredMtx.Lock()
+	defer aminoRegisteredMtx.Unlock()
+
+	if !aminoRegistered[cdc] {
+		ctypes.RegisterAmino(cdc)
+		aminoRegistered[cdc] = true
+	}
+}
+
+// Broadcaster forwards the txs sent through the go-ethereum rpc to the tendermint mempool.
+// The TxInfo of each tx is kept by hash while tendermint runs its local CheckTx.
+type Broadcaster struct {
+	backend *Backend
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	state  int32 // read atomically by the health checks
+
+	mtx     sync.Mutex
+	txInfos map[common.Hash]ethTypes.TxInfo
+}
+
+// NewBroadcaster creates a broadcaster for the txpool of backend
+func NewBroadcaster(backend *Backend) *Broadcaster {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Broadcaster{
+		backend: backend,
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		txInfos: make(map[common.Hash]ethTypes.TxInfo),
+	}
+}
+
+// Start starts the broadcast loop, it waits for the tendermint rpc first
+func (br *Broadcaster) Start() {
+	atomic.StoreInt32(&br.state, broadcastWaiting)
+	go br.loop()
+}
+
+// Stop stops the broadcast loop and waits for it to return.
+// The txs not broadcast yet are answered with an error.
+func (br *Broadcaster) Stop() {
+	br.cancel()
+	if atomic.LoadInt32(&br.state) != broadcastNotStarted {
+		<-br.done
+	}
+}
+
+// State returns the state of the broadcast loop
+func (br *Broadcaster) State() int32 {
+	return atomic.LoadInt32(&br.state)
+}
+
+// TxInfo returns the TxInfo of a tx in the local CheckTx of tendermint
+func (br *Broadcaster) TxInfo(hash common.Hash) (ethTypes.TxInfo, bool) {
+	br.mtx.Lock()
+	defer br.mtx.Unlock()
+
+	txInfo, ok := br.txInfos[hash]
+	return txInfo, ok
+}
+
+func (br *Broadcaster) loop() {
+	defer close(br.done)
+	defer atomic.StoreInt32(&br.state, broadcastStopped)
+
+	txPool := br.backend.Ethereum().TxPool()
+	ch := make(chan core.TxPreEvent, 50000)
+	sub := txPool.SubscribeTxPreEvent(ch)
+	defer func() {
+		sub.Unsubscribe()
+		// the senders wait for the result of the txs already queued
+		for {
+			select {
+			case obj := <-ch:
+				broadcastStoppedCounter.Inc(1)
+				br.backend.txTracker.Record(obj.Tx.Hash(), TxStageTxpoolRejected, errBroadcasterStopped.Error(), 0)
+				obj.Result <- errBroadcasterStopped
+			default:
+				return
+			}
+		}
+	}()
+
+	if !waitForServer(br.ctx, br.backend.client) {
+		return
+	}
+	atomic.StoreInt32(&br.state, broadcastRunning)
+	txPool.HandleJournalTxs()
+	txCount := 0
+	for {
+		select {
+		case <-br.ctx.Done():
+			return
+		case err := <-sub.Err():
+			log.Error("TxPreEvent subscription failed", "err", err)
+			return
+		case obj := <-ch:
+			txInfo := ethTypes.TxInfo{
+				Tx:        obj.Tx,
+				From:      obj.From,
+				RelayFrom: obj.Relayer,
+				SubTx:     obj.SubTx,
+			}
+			if err := br.broadcast(txInfo); err != nil {
+				log.Error("Broadcast error", "hash", obj.Tx.Hash(), "err", err)
+				obj.Result <- err
+				go txPool.RemoveTx(obj.Tx.Hash()) //start a goroutine to avoid deadlock
+			} else {
+				obj.Result <- nil
+			}
+			if txCount > 1<<10 {
+				txPool.SetFlowLimit(br.backend.memPool.Size())
+				txCount = 0
+			}
+			txCount++
+		}
+	}
+}
+
+// broadcast runs the local CheckTx of tendermint, again with a growing delay while the mempool is full
+func (br *Broadcaster) broadcast(txInfo ethTypes.TxInfo) error {
+	hash := txInfo.Tx.Hash()
+	br.mtx.Lock()
+	br.txInfos[hash] = txInfo
+	br.mtx.Unlock()
+	defer func() {
+		br.mtx.Lock()
+		delete(br.txInfos, hash)
+		br.mtx.Unlock()
+	}()
+
+	delay := broadcastRetryDelay
+	for attempt := 0; ; attempt++ {
+		err := br.backend.BroadcastTx(txInfo.Tx)
+		var full mempl.ErrMempoolIsFull
+		switch {
+		case err == nil:
+			broadcastOkCounter.Inc(1)
+			return nil
+		case !errors.As(err, &full):
+			broadcastFailedCounter.Inc(1)
+			return err
+		case attempt == broadcastRetries:
+			broadcastMempoolFullCounter.Inc(1)
+			return err
+		}
+		broadcastRetryCounter.Inc(1)
+		select {
+		case <-br.ctx.Done():
+			broadcastStoppedCounter.Inc(1)
+			br.backend.txTracker.Record(hash, TxStageTxpoolRejected, errBroadcasterStopped.Error(), 0)
+			return errBroadcasterStopped
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// waitForServer waits for Tendermint to open the socket and run the http endpoint,
+// it returns false if ctx is done first
+func waitForServer(ctx context.Context, c rpcClient.HTTPClient) bool {
+	registerAmino(c.Codec())
+	result := new(ctypes.ResultStatus)
+
+	for {
+		_, err := c.Call("status", map[string]interface{}{}, result)
+		if err == nil {
+			return true
+		}
+
+		log.Info("Waiting for tendermint endpoint to start", "err", err)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(waitForServerDelay):
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	amino "github.com/tendermint/go-amino"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+	mempl "github.com/tendermint/tendermint/mempool"
+	rpcClient "github.com/tendermint/tendermint/rpc/lib/client"
+	tmTypes "github.com/tendermint/tendermint/types"
+)
+
+// fullMempool is full for the first `full` local CheckTx calls, then it accepts the txs
+type fullMempool struct {
+	mempl.Mempool
+	full  int
+	calls int
+}
+
+func (mem *fullMempool) CheckTxLocal(tx tmTypes.Tx, cb func(*abciTypes.Response)) error {
+	mem.calls++
+	if mem.calls <= mem.full {
+		return mempl.ErrMempoolIsFull{}
+	}
+	cb(abciTypes.ToResponseCheckTx(abciTypes.ResponseCheckTx{Code: abciTypes.CodeTypeOK}))
+	return nil
+}
+
+func TestWaitForServerCancel(t *testing.T) {
+	// nothing listens on the port
+	client := rpcClient.NewURIClient("tcp://127.0.0.1:1")
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	done := make(chan bool)
+	go func() {
+		done <- waitForServer(ctx, client)
+	}()
+	select {
+	case ok := <-done:
+		assert.False(t, ok, "expecting waitForServer to give up")
+	case <-time.After(2 * waitForServerDelay):
+		t.Fatal("waitForServer ignored the cancellation")
+	}
+}
+
+func TestBroadcasterStopNotStarted(t *testing.T) {
+	broadcaster := NewBroadcaster(nil)
+	broadcaster.Stop()
+	assert.Equal(t, broadcastNotStarted, broadcaster.State())
+	_, ok := broadcaster.TxInfo(common.Hash{})
+	assert.False(t, ok)
+}
+
+func TestBroadcastMempoolFull(t *testing.T) {
+	mempool := &fullMempool{full: 2}
+	broadcaster := NewBroadcaster(&Backend{memPool: mempool, txTracker: NewTxTracker(time.Minute)})
+	tx := ethTypes.NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(0), nil)
+
+	assert.NoError(t, broadcaster.broadcast(ethTypes.TxInfo{Tx: tx}))
+	assert.Equal(t, 3, mempool.calls)
+	_, ok := broadcaster.TxInfo(tx.Hash())
+	assert.False(t, ok, "the TxInfo is kept after the broadcast")
+}
+
+func TestBroadcastMempoolFullStopped(t *testing.T) {
+	mempool := &fullMempool{full: broadcastRetries + 1}
+	backend := &Backend{memPool: mempool, txTracker: NewTxTracker(time.Minute)}
+	broadcaster := NewBroadcaster(backend)
+	tx := ethTypes.NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(0), nil)
+	go func() {
+		time.Sleep(broadcastRetryDelay / 2)
+		broadcaster.cancel()
+	}()
+
+	// the retry waiting for the mempool gives up once the broadcaster stops
+	assert.Equal(t, errBroadcasterStopped, broadcaster.broadcast(ethTypes.TxInfo{Tx: tx}))
+	assert.Equal(t, 1, mempool.calls)
+	status, ok := backend.txTracker.Status(tx.Hash())
+	assert.True(t, ok)
+	assert.Equal(t, TxStageTxpoolRejected, status.Stage)
+}
+
+func TestRegisterAminoPerCodec(t *testing.T) {
+	first, second := amino.NewCodec(), amino.NewCodec()
+	registerAmino(first)
+	// a second registration on the same codec would panic
+	registerAmino(first)
+	registerAmino(second)
+	assert.True(t, aminoRegistered[first])
+	assert.True(t, aminoRegistered[second])
+}
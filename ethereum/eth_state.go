@@ -22,6 +22,7 @@ import (
 	"encoding/hex"
 	"github.com/ethereum/go-ethereum/log"
 	emtTypes "github.com/DTFN/dtfn/types"
+	"github.com/DTFN/dtfn/version"
 	"time"
 	"github.com/ethereum/go-ethereum/trie"
 )
@@ -45,6 +46,19 @@ type EthState struct {
 
 	mtx  sync.Mutex
 	work workState // latest working state
+
+	// blockGasLimit is the consensus block gas limit, 0 falls back to core.CalcGasLimit
+	blockGasLimit uint64
+
+	// pendingState returns a copy of the mempool adjusted check tx state, nil falls back to the work state
+	pendingState func() *state.StateDB
+
+	rewards rewardHistory
+
+	// commit progress for the health checks, guarded by its own mutex so they never wait on mtx
+	statusMtx     sync.Mutex
+	commitStarted time.Time // zero when no commit is running
+	lastCommit    time.Time
 }
 
 type ChainError struct {
@@ -89,10 +103,27 @@ func (es *EthState) DeliverTx(tx *ethTypes.Transaction, appVersion uint64, txInf
 
 	blockchain := es.ethereum.BlockChain()
 	chainConfig := blockchain.Config()
+	// the block hash depends on the state root, it is set on the receipts and logs at commit
 	blockHash := common.Hash{}
 	return es.work.deliverTx(blockchain, es.ethConfig, chainConfig, blockHash, tx, txInfo)
 }
 
+// AddTxHashMapping remembers the tendermint hash of a delivered tx until the block is committed.
+func (es *EthState) AddTxHashMapping(tmHash []byte, ethHash common.Hash) {
+	es.mtx.Lock()
+	defer es.mtx.Unlock()
+
+	es.work.txHashes = append(es.work.txHashes, txHashMapping{tmHash: tmHash, ethHash: ethHash})
+}
+
+// AddSlashRecords keeps the slashing journal entries of the block until it is committed.
+func (es *EthState) AddSlashRecords(records []*emtTypes.SlashRecord) {
+	es.mtx.Lock()
+	defer es.mtx.Unlock()
+
+	es.work.slashes = append(es.work.slashes, records...)
+}
+
 // Accumulate validator rewards.
 func (es *EthState) AccumulateRewards(strategy *emtTypes.Strategy) {
 	es.mtx.Lock()
@@ -108,6 +139,8 @@ func (es *EthState) AccumulateRewards(strategy *emtTypes.Strategy) {
 
 // Commit and reset the work.
 func (es *EthState) Commit() (common.Hash, error) {
+	es.setCommitStarted()
+	defer es.setCommitEnded()
 	es.mtx.Lock()
 	defer es.mtx.Unlock()
 
@@ -115,6 +148,8 @@ func (es *EthState) Commit() (common.Hash, error) {
 	if err != nil {
 		return common.Hash{}, err
 	}
+	es.setLastCommit()
+	es.rewards.add(es.work.height, es.work.rewards)
 
 	ws := &es.work
 	err = es.resetWorkState(ws.header.Coinbase) //built for nextHeight, the coinbase in the header will later be overwritten in the next height
@@ -125,6 +160,40 @@ func (es *EthState) Commit() (common.Hash, error) {
 	return blockHash, err
 }
 
+func (es *EthState) setCommitStarted() {
+	es.statusMtx.Lock()
+	defer es.statusMtx.Unlock()
+
+	es.commitStarted = time.Now()
+}
+
+func (es *EthState) setCommitEnded() {
+	es.statusMtx.Lock()
+	defer es.statusMtx.Unlock()
+
+	es.commitStarted = time.Time{}
+}
+
+func (es *EthState) setLastCommit() {
+	es.statusMtx.Lock()
+	defer es.statusMtx.Unlock()
+
+	es.lastCommit = time.Now()
+}
+
+// CommitStatus returns when the running commit started, zero if none runs, and when the last one succeeded
+func (es *EthState) CommitStatus() (commitStarted time.Time, lastCommit time.Time) {
+	es.statusMtx.Lock()
+	defer es.statusMtx.Unlock()
+
+	return es.commitStarted, es.lastCommit
+}
+
+// RecentRewards returns the rewards of beneficiary in the last committed blocks
+func (es *EthState) RecentRewards(beneficiary common.Address) *RecentRewards {
+	return es.rewards.rewardsOf(beneficiary)
+}
+
 func (es *EthState) WorkState() workState {
 	return es.work
 }
@@ -145,7 +214,11 @@ func (es *EthState) resetWorkState(receiver common.Address) error {
 	}
 
 	currentBlock := blockchain.CurrentBlock()
-	ethHeader := newBlockHeader(receiver, currentBlock)
+	ethHeader := newBlockHeader(receiver, currentBlock, es.blockGasLimit)
+	baseFee := CalcBaseFee(currentBlock.Header(), ethHeader.Number.Int64())
+	if baseFee != nil {
+		ethHeader.Extra = baseFee.Bytes()
+	}
 
 	es.work = workState{
 		header:       ethHeader,
@@ -155,6 +228,7 @@ func (es *EthState) resetWorkState(receiver common.Address) error {
 		txIndex:      0,
 		totalUsedGas: new(uint64),
 		gp:           new(core.GasPool).AddGas(ethHeader.GasLimit),
+		baseFee:      baseFee,
 	}
 	return nil
 }
@@ -177,16 +251,53 @@ func (es *EthState) UpdateHeaderCoinbase(
 	es.work.updateHeaderCoinbase(coinbase)
 }
 
+// SetBlockGasLimit sets the gas limit of the blocks built from the next work state reset.
+func (es *EthState) SetBlockGasLimit(gasLimit uint64) {
+	es.mtx.Lock()
+	defer es.mtx.Unlock()
+
+	es.blockGasLimit = gasLimit
+}
+
 func (es *EthState) GasLimit() uint64 {
 	return es.work.gp.Gas()
 }
 
+// BaseFee returns the base fee of the block in execution, nil if it is not tracked yet.
+func (es *EthState) BaseFee() *big.Int {
+	es.mtx.Lock()
+	defer es.mtx.Unlock()
+
+	return es.work.baseFee
+}
+
 //----------------------------------------------------------------------
 // Implements: miner.Pending API (our custom patch to go-ethereum)
 
+// SetPendingState sets the source of the pending state, the check tx state of the app.
+func (es *EthState) SetPendingState(pendingState func() *state.StateDB) {
+	es.mtx.Lock()
+	defer es.mtx.Unlock()
+
+	es.pendingState = pendingState
+}
+
 // Return a new block and a copy of the state from the latest work.
+// With a pending state source the block is the header in execution without txs and the state
+// is the check tx state, so it accounts for the txs already in the tendermint mempool.
 // #unstable
 func (es *EthState) Pending() (*ethTypes.Block, *state.StateDB) {
+	es.mtx.Lock()
+	pendingState := es.pendingState
+	es.mtx.Unlock()
+	if pendingState != nil {
+		// taken out of es.mtx, the app locks the check tx state
+		checkTxState := pendingState()
+		es.mtx.Lock()
+		defer es.mtx.Unlock()
+		return ethTypes.NewBlockWithHeader(es.work.header), checkTxState
+	}
+
 	es.mtx.Lock()
 	defer es.mtx.Unlock()
 
@@ -218,6 +329,14 @@ type workState struct {
 
 	totalUsedGas *uint64
 	gp           *core.GasPool
+
+	baseFee *big.Int // stored in header.Extra, nil before version.BaseFeeHeight
+
+	txHashes []txHashMapping // written to the chain db on commit
+
+	slashes []*emtTypes.SlashRecord // written to the chain db on commit
+
+	rewards map[common.Address]*big.Int // added to the reward history on commit
 }
 
 func (ws *workState) State() *state.StateDB {
@@ -228,6 +347,18 @@ func (ws workState) Height() int64 {
 	return ws.height
 }
 
+// addReward records a reward paid to beneficiary in the block
+func (ws *workState) addReward(beneficiary common.Address, amount *big.Int) {
+	if ws.rewards == nil {
+		ws.rewards = make(map[common.Address]*big.Int)
+	}
+	if total, ok := ws.rewards[beneficiary]; ok {
+		total.Add(total, amount)
+	} else {
+		ws.rewards[beneficiary] = new(big.Int).Set(amount)
+	}
+}
+
 // nolint: unparam
 func (ws *workState) accumulateRewards(strategy *emtTypes.Strategy) {
 	//ws.state.AddBalance(ws.header.Coinbase, ethash.FrontierBlockReward)
@@ -241,6 +372,7 @@ func (ws *workState) accumulateRewards(strategy *emtTypes.Strategy) {
 		minerBonus.Div(strategy.CurrEpochValData.TotalBalance, divisor.Mul(big.NewInt(100), big.NewInt(365*24*60*60/5)))
 	} else {
 		ws.state.AddBalance(ws.CurrentHeader().Coinbase, minerBonus)
+		ws.addReward(ws.CurrentHeader().Coinbase, minerBonus)
 		//log.Info(fmt.Sprintf("proposer %v , Beneficiary address: %v, get money: %v",
 		//	strategy.CurrentHeightValData.ProposerAddress, ws.CurrentHeader().Coinbase.String(), minerBonus))
 	}
@@ -284,8 +416,10 @@ func (ws *workState) accumulateRewards(strategy *emtTypes.Strategy) {
 		}
 		if strategy.HFExpectedData.BlockVersion >= 3 {
 			ws.state.AddBalance(beneficiary, bonusSpecify)
+			ws.addReward(beneficiary, bonusSpecify)
 		} else {
 			ws.state.AddBalance(beneficiary, bonusAverage) //bug
+			ws.addReward(beneficiary, bonusAverage)
 		}
 
 		//log.Info(fmt.Sprintf("validator %v , Beneficiary address: %v, get money: %v power: %v validator address: %v",
@@ -338,7 +472,7 @@ func (ws *workState) deliverTx(blockchain *core.BlockChain, config *eth.Config,
 
 	if err != nil {
 		log.Error(fmt.Sprintf("Deliver Tx: from %X txHash %X err %v", msg.From(), tx.Hash(), err))
-		return abciTypes.ResponseDeliverTx{Code: errorCode, Log: err.Error()}
+		return abciTypes.ResponseDeliverTx{Code: errorCode, Log: err.Error(), GasWanted: int64(tx.Gas())}
 	}
 	log.Info(fmt.Sprintf("Deliver Tx: from %X txHash %X", msg.From(), tx.Hash()))
 
@@ -351,7 +485,19 @@ func (ws *workState) deliverTx(blockchain *core.BlockChain, config *eth.Config,
 	ws.receipts = append(ws.receipts, receipt)
 	ws.allLogs = append(ws.allLogs, logs...)
 
-	return abciTypes.ResponseDeliverTx{Code: abciTypes.CodeTypeOK}
+	res := abciTypes.ResponseDeliverTx{
+		Code:      abciTypes.CodeTypeOK,
+		GasWanted: int64(tx.Gas()),
+		GasUsed:   int64(receipt.GasUsed),
+		Events:    append([]abciTypes.Event{ethTxEvent(tx, txInfo)}, receiptEvents(receipt)...),
+	}
+	if tx.To() == nil {
+		res.Data = receipt.ContractAddress.Bytes()
+	}
+	if receipt.Status == ethTypes.ReceiptStatusFailed {
+		res.Log = "tx execution failed"
+	}
+	return res
 }
 
 // Commit the ethereum state, update the header, make a new block and add it to
@@ -367,20 +513,20 @@ func (ws *workState) commit(blockchain *core.BlockChain, db ethdb.Database) (com
 
 	ws.header.Root = hashArray
 
-	for _, log := range ws.allLogs {
-		log.BlockHash = hashArray
-	}
-
-	for _, r := range ws.receipts {
-		for _, l := range r.Logs {
-			l.BlockHash = hashArray
-		}
+	realBlockHash := version.ReceiptBlockHashHeight > 0 && ws.height >= version.ReceiptBlockHashHeight
+	if !realBlockHash {
+		// before the fork height, logs carry the state root
+		setReceiptsBlockHash(ws.receipts, ws.allLogs, hashArray)
 	}
 
 	// Create block object and compute final commit hash (hash of the ethereum
 	// block).
 	block := ethTypes.NewBlock(ws.header, ws.transactions, nil, ws.receipts, new(trie.Trie))
 	blockHash := block.Hash()
+	if realBlockHash {
+		// the receipt root only covers the consensus fields, it does not depend on the block hash
+		setReceiptsBlockHash(ws.receipts, ws.allLogs, blockHash)
+	}
 
 	log.Info(fmt.Sprintf("eth_state commit. block.header %v blockHash %X",
 		block.Header(), blockHash))
@@ -395,22 +541,31 @@ func (ws *workState) commit(blockchain *core.BlockChain, db ethdb.Database) (com
 		log.Error("Failed writing block to chain", "err", err)
 		return common.Hash{}, err
 	}
-	// check if canon block and write transactions
-	/*	var (
-			events []interface{}
-			//logs   = work.state.Logs()
-		)
-		events = append(events, core.ChainEvent{Block: block, Hash: block.Hash(), Logs: ws.allLogs})
-		if stat == core.CanonStatTy {
-			events = append(events, core.ChainHeadEvent{Block: block}) //此事件更新txPool
-		} else {
-			err = chainError(1, "WriteBlockWithState return stat not CanonStatTy")
-			log.Error("stat not core.CanonStatTy", "workState", ws)
-		}*/
+	batch := db.NewBatch()
+	for _, mapping := range ws.txHashes {
+		if err := WriteTxHashMapping(batch, mapping.tmHash, mapping.ethHash); err != nil {
+			log.Error("Failed writing tx hash mapping", "ethHash", mapping.ethHash, "err", err)
+		}
+	}
+	for _, record := range ws.slashes {
+		if err := WriteSlashRecord(batch, record); err != nil {
+			log.Error("Failed writing slash record", "height", record.Height, "signer", record.Signer, "err", err)
+		}
+	}
+	if err := batch.Write(); err != nil {
+		log.Error("Failed writing tx hash mappings and slash records", "err", err)
+	}
+	// WriteBlockWithState already emits the ChainHeadEvent that resets the txpool,
+	// only the chain and log events for the eth_subscribe clients are posted here.
+	if stat == core.CanonStatTy {
+		events := []interface{}{core.ChainEvent{Block: block, Hash: blockHash, Logs: ws.allLogs}}
+		blockchain.PostChainEvents(events, ws.allLogs)
+	} else {
+		log.Error("stat not core.CanonStatTy, no chain event posted", "height", ws.height, "stat", stat)
+	}
 	/*blockchain.mux.Post(core.NewMinedBlockEvent{Block: block})
 	交易通过tendermint广播，此事件不用发
 	*/
-	//blockchain.PostChainEvents(events, ws.allLogs)
 	// Save the block to disk.
 	log.Info("Committing block", "stateHash", hashArray, "blockHash", blockHash, "stat", stat)
 	/*	_, err = blockchain.InsertChain([]*ethTypes.Block{block})
@@ -421,6 +576,19 @@ func (ws *workState) commit(blockchain *core.BlockChain, db ethdb.Database) (com
 	return blockHash, err
 }
 
+// setReceiptsBlockHash sets the block hash known at commit, deliverTx only had an empty one
+func setReceiptsBlockHash(receipts ethTypes.Receipts, logs []*ethTypes.Log, blockHash common.Hash) {
+	for _, l := range logs {
+		l.BlockHash = blockHash
+	}
+	for _, r := range receipts {
+		r.BlockHash = blockHash
+		for _, l := range r.Logs {
+			l.BlockHash = blockHash
+		}
+	}
+}
+
 func (ws *workState) updateHeaderCoinbase(coinbase common.Address) {
 	ws.header.Coinbase = coinbase
 }
@@ -448,11 +616,15 @@ func (ws *workState) CurrentHeader() *ethTypes.Header {
 }
 
 // Create a new block header from the previous block.
-func newBlockHeader(receiver common.Address, prevBlock *ethTypes.Block) *ethTypes.Header {
+// gasLimit is the consensus block gas limit, 0 keeps the legacy core.CalcGasLimit behaviour.
+func newBlockHeader(receiver common.Address, prevBlock *ethTypes.Block, gasLimit uint64) *ethTypes.Header {
+	if gasLimit == 0 {
+		gasLimit = core.CalcGasLimit(prevBlock)
+	}
 	return &ethTypes.Header{
 		Number:     prevBlock.Number().Add(prevBlock.Number(), big.NewInt(1)),
 		ParentHash: prevBlock.Hash(),
-		GasLimit:   core.CalcGasLimit(prevBlock),
+		GasLimit:   gasLimit,
 		Coinbase:   receiver,
 	}
 }
@@ -0,0 +1,60 @@
+package ethereum
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+	tmlibs "github.com/tendermint/tendermint/libs/common"
+)
+
+// receiptEvents converts the execution result of a tx into ABCI events,
+// one "tx" event with the receipt status and one "log" event per eth log
+// with its address and topic0..topic3.
+func receiptEvents(receipt *ethTypes.Receipt) []abciTypes.Event {
+	txEvent := abciTypes.Event{Type: "tx"}
+	txEvent.Attributes = append(txEvent.Attributes,
+		kvPair("status", strconv.FormatUint(receipt.Status, 10)),
+		kvPair("gasUsed", strconv.FormatUint(receipt.GasUsed, 10)))
+	if receipt.ContractAddress != (common.Address{}) {
+		txEvent.Attributes = append(txEvent.Attributes, kvPair("contractAddress", receipt.ContractAddress.Hex()))
+	}
+
+	events := []abciTypes.Event{txEvent}
+	for _, l := range receipt.Logs {
+		logEvent := abciTypes.Event{Type: "log"}
+		logEvent.Attributes = append(logEvent.Attributes, kvPair("address", addressString(l.Address)))
+		for i, topic := range l.Topics {
+			logEvent.Attributes = append(logEvent.Attributes, kvPair("topic"+strconv.Itoa(i), topic.Hex()))
+		}
+		events = append(events, logEvent)
+	}
+	return events
+}
+
+// ethTxEvent makes the eth tx searchable through tendermint tx_search, e.g.
+// tx_search "eth.hash='0x...'" or "eth.from='0x...'".
+func ethTxEvent(tx *ethTypes.Transaction, txInfo ethTypes.TxInfo) abciTypes.Event {
+	ethEvent := abciTypes.Event{Type: "eth"}
+	ethEvent.Attributes = append(ethEvent.Attributes,
+		kvPair("hash", tx.Hash().Hex()),
+		kvPair("from", addressString(txInfo.From)))
+	if tx.To() != nil {
+		ethEvent.Attributes = append(ethEvent.Attributes, kvPair("to", addressString(*tx.To())))
+	}
+	if txInfo.SubTx != nil {
+		ethEvent.Attributes = append(ethEvent.Attributes, kvPair("relayer", addressString(txInfo.RelayFrom)))
+	}
+	return ethEvent
+}
+
+// addresses are indexed lower case so queries do not depend on the checksum
+func addressString(address common.Address) string {
+	return strings.ToLower(address.Hex())
+}
+
+func kvPair(key, value string) tmlibs.KVPair {
+	return tmlibs.KVPair{Key: []byte(key), Value: []byte(value)}
+}
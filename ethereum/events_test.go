@@ -0,0 +1,74 @@
+package ethereum
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+)
+
+func eventAttributes(event abciTypes.Event) map[string]string {
+	attributes := make(map[string]string)
+	for _, pair := range event.Attributes {
+		attributes[string(pair.Key)] = string(pair.Value)
+	}
+	return attributes
+}
+
+func TestEthTxEvent(t *testing.T) {
+	from := common.HexToAddress("0x00000000000000000000000000000000000000Ab")
+	to := common.HexToAddress("0x00000000000000000000000000000000000000Cd")
+	tx := ethTypes.NewTransaction(0, to, big.NewInt(1), 21000, big.NewInt(1), nil)
+
+	event := ethTxEvent(tx, ethTypes.TxInfo{Tx: tx, From: from})
+	assert.Equal(t, "eth", event.Type)
+	attributes := eventAttributes(event)
+	assert.Equal(t, tx.Hash().Hex(), attributes["hash"])
+	assert.Equal(t, strings.ToLower(from.Hex()), attributes["from"])
+	assert.Equal(t, strings.ToLower(to.Hex()), attributes["to"])
+	_, ok := attributes["relayer"]
+	assert.False(t, ok)
+
+	relayer := common.HexToAddress("0x00000000000000000000000000000000000000eF")
+	event = ethTxEvent(tx, ethTypes.TxInfo{Tx: tx, From: from, SubTx: tx, RelayFrom: relayer})
+	assert.Equal(t, strings.ToLower(relayer.Hex()), eventAttributes(event)["relayer"])
+
+	// a contract creation has no recipient
+	creation := ethTypes.NewContractCreation(0, big.NewInt(0), 100000, big.NewInt(1), nil)
+	_, ok = eventAttributes(ethTxEvent(creation, ethTypes.TxInfo{Tx: creation, From: from}))["to"]
+	assert.False(t, ok)
+}
+
+func TestReceiptEvents(t *testing.T) {
+	contract := common.HexToAddress("0x00000000000000000000000000000000000000Ab")
+	receipt := &ethTypes.Receipt{
+		Status:          ethTypes.ReceiptStatusSuccessful,
+		GasUsed:         21000,
+		ContractAddress: contract,
+		Logs: []*ethTypes.Log{
+			{Address: contract, Topics: []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")}},
+			{Address: contract},
+		},
+	}
+
+	events := receiptEvents(receipt)
+	assert.Len(t, events, 3)
+	assert.Equal(t, "tx", events[0].Type)
+	txAttributes := eventAttributes(events[0])
+	assert.Equal(t, "1", txAttributes["status"])
+	assert.Equal(t, "21000", txAttributes["gasUsed"])
+	assert.Equal(t, contract.Hex(), txAttributes["contractAddress"])
+
+	logAttributes := eventAttributes(events[1])
+	assert.Equal(t, "log", events[1].Type)
+	assert.Equal(t, strings.ToLower(contract.Hex()), logAttributes["address"])
+	assert.Equal(t, common.HexToHash("0x01").Hex(), logAttributes["topic0"])
+	assert.Equal(t, common.HexToHash("0x02").Hex(), logAttributes["topic1"])
+	_, ok := logAttributes["topic2"]
+	assert.False(t, ok)
+	assert.Len(t, events[2].Attributes, 1)
+}
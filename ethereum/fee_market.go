@@ -0,0 +1,94 @@
+package ethereum
+
+import (
+	"math/big"
+
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/DTFN/dtfn/version"
+)
+
+const (
+	// baseFeeChangeDenominator bounds the amount the base fee can change between blocks
+	baseFeeChangeDenominator = 8
+	// elasticityMultiplier bounds the maximum gas limit a block may have
+	elasticityMultiplier = 2
+)
+
+// IsBaseFeeEnabled reports whether the base fee is tracked at the given height
+func IsBaseFeeEnabled(height int64) bool {
+	return version.BaseFeeHeight > 0 && height >= version.BaseFeeHeight
+}
+
+// minBaseFee is the floor of the base fee, the consensus min gas price if it is set.
+func minBaseFee() *big.Int {
+	if version.MinGasPrice != nil && version.MinGasPrice.Sign() > 0 {
+		return new(big.Int).Set(version.MinGasPrice)
+	}
+	return big.NewInt(1)
+}
+
+// BaseFeeOf reads the base fee stored in the header extra data, nil if there is none.
+func BaseFeeOf(header *ethTypes.Header) *big.Int {
+	if header == nil || len(header.Extra) == 0 {
+		return nil
+	}
+	return new(big.Int).SetBytes(header.Extra)
+}
+
+// CalcBaseFee calculates the base fee of the block following parent.
+// It follows EIP-1559: the fee moves toward keeping blocks half full,
+// by at most 1/8 per block, and never drops below minBaseFee.
+func CalcBaseFee(parent *ethTypes.Header, height int64) *big.Int {
+	if !IsBaseFeeEnabled(height) {
+		return nil
+	}
+	parentBaseFee := BaseFeeOf(parent)
+	if parentBaseFee == nil || !IsBaseFeeEnabled(parent.Number.Int64()) {
+		return minBaseFee()
+	}
+
+	parentGasTarget := parent.GasLimit / elasticityMultiplier
+	if parentGasTarget == 0 || parent.GasUsed == parentGasTarget {
+		return parentBaseFee
+	}
+
+	baseFee := new(big.Int)
+	if parent.GasUsed > parentGasTarget {
+		gasUsedDelta := new(big.Int).SetUint64(parent.GasUsed - parentGasTarget)
+		delta := gasUsedDelta.Mul(gasUsedDelta, parentBaseFee)
+		delta.Div(delta, new(big.Int).SetUint64(parentGasTarget))
+		delta.Div(delta, big.NewInt(baseFeeChangeDenominator))
+		if delta.Sign() == 0 {
+			delta.SetInt64(1)
+		}
+		baseFee.Add(parentBaseFee, delta)
+	} else {
+		gasUsedDelta := new(big.Int).SetUint64(parentGasTarget - parent.GasUsed)
+		delta := gasUsedDelta.Mul(gasUsedDelta, parentBaseFee)
+		delta.Div(delta, new(big.Int).SetUint64(parentGasTarget))
+		delta.Div(delta, big.NewInt(baseFeeChangeDenominator))
+		baseFee.Sub(parentBaseFee, delta)
+	}
+
+	if floor := minBaseFee(); baseFee.Cmp(floor) < 0 {
+		return floor
+	}
+	return baseFee
+}
+
+// TxPriority returns the priority of a tx for block proposals.
+// It is the tip above the base fee, or the gas price if there is no base fee.
+func TxPriority(tx *ethTypes.Transaction, baseFee *big.Int) int64 {
+	tip := new(big.Int).Set(tx.GasPrice())
+	if baseFee != nil {
+		tip.Sub(tip, baseFee)
+	}
+	if tip.Sign() < 0 {
+		return 0
+	}
+	if !tip.IsInt64() {
+		return int64(^uint64(0) >> 1)
+	}
+	return tip.Int64()
+}
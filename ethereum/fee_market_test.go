@@ -0,0 +1,50 @@
+package ethereum
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/DTFN/dtfn/version"
+)
+
+func parentWithBaseFee(number int64, gasLimit, gasUsed uint64, baseFee int64) *ethTypes.Header {
+	return &ethTypes.Header{
+		Number:   big.NewInt(number),
+		GasLimit: gasLimit,
+		GasUsed:  gasUsed,
+		Extra:    big.NewInt(baseFee).Bytes(),
+	}
+}
+
+func TestCalcBaseFee(t *testing.T) {
+	version.BaseFeeHeight = 10
+	version.MinGasPrice = big.NewInt(100)
+	defer func() {
+		version.BaseFeeHeight = 0
+		version.MinGasPrice = big.NewInt(0)
+	}()
+
+	// disabled before the fork height
+	assert.Nil(t, CalcBaseFee(parentWithBaseFee(8, 1000, 0, 0), 9))
+	// starts at the consensus minimum
+	assert.Equal(t, big.NewInt(100), CalcBaseFee(&ethTypes.Header{Number: big.NewInt(9), GasLimit: 1000}, 10))
+	// unchanged at target
+	assert.Equal(t, big.NewInt(800), CalcBaseFee(parentWithBaseFee(10, 1000, 500, 800), 11))
+	// full block raises it by 1/8
+	assert.Equal(t, big.NewInt(900), CalcBaseFee(parentWithBaseFee(10, 1000, 1000, 800), 11))
+	// empty block lowers it by 1/8
+	assert.Equal(t, big.NewInt(700), CalcBaseFee(parentWithBaseFee(10, 1000, 0, 800), 11))
+	// never below the minimum
+	assert.Equal(t, big.NewInt(100), CalcBaseFee(parentWithBaseFee(10, 1000, 0, 105), 11))
+}
+
+func TestTxPriority(t *testing.T) {
+	tx := ethTypes.NewTransaction(0, [20]byte{}, big.NewInt(0), 21000, big.NewInt(500), nil)
+	assert.Equal(t, int64(500), TxPriority(tx, nil))
+	assert.Equal(t, int64(200), TxPriority(tx, big.NewInt(300)))
+	assert.Equal(t, int64(0), TxPriority(tx, big.NewInt(800)))
+}
@@ -0,0 +1,166 @@
+package ethereum
+
+import (
+	"fmt"
+	"time"
+
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+// Broadcaster loop states
+const (
+	broadcastNotStarted int32 = iota
+	broadcastWaiting          // waiting for the tendermint rpc
+	broadcastRunning
+	broadcastStopped
+)
+
+// tendermintRPCTimeout bounds the tendermint rpc calls of the health checks and the sync status
+const tendermintRPCTimeout = 3 * time.Second
+
+// HealthConfig holds the thresholds of the health checks
+type HealthConfig struct {
+	// MaxCommitAge is how old the latest block may be, 0 disables the check
+	MaxCommitAge time.Duration
+	// MaxCommitDuration is how long an EthState commit may run before the state counts as stuck
+	MaxCommitDuration time.Duration
+	// MaxTxpoolBacklog is how many chain head events the txpool may have left unread
+	MaxTxpoolBacklog int
+}
+
+// DefaultHealthConfig returns the default thresholds, the latest block age is not checked
+func DefaultHealthConfig() HealthConfig {
+	return HealthConfig{
+		MaxCommitDuration: time.Minute,
+		MaxTxpoolBacklog:  1024,
+	}
+}
+
+// HealthCheck is the result of one check
+type HealthCheck struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Message string `json:"message,omitempty"`
+}
+
+// HealthReport is the result of a set of checks, healthy if all of them are
+type HealthReport struct {
+	Healthy bool           `json:"healthy"`
+	Checks  []*HealthCheck `json:"checks"`
+}
+
+func newHealthReport(checks ...*HealthCheck) *HealthReport {
+	report := &HealthReport{Healthy: true, Checks: checks}
+	for _, check := range checks {
+		report.Healthy = report.Healthy && check.Healthy
+	}
+	return report
+}
+
+// SetHealthConfig sets the thresholds of the health checks
+func (b *Backend) SetHealthConfig(config HealthConfig) {
+	b.healthConfig = config
+}
+
+// Liveness runs the checks failing only when the process must be restarted: the EthState commit is stuck.
+// A tendermint rpc still starting or a stopped Broadcaster is reported by Readiness.
+func (b *Backend) Liveness() *HealthReport {
+	return newHealthReport(b.checkEthState())
+}
+
+// Readiness runs the liveness checks plus the ones telling if the node can serve traffic:
+// the tendermint rpc answers, the Broadcaster runs, the latest block is recent,
+// the txpool keeps up with the chain head, tendermint caught up and the node is not shutting down.
+func (b *Backend) Readiness() *HealthReport {
+	return newHealthReport(b.checkTendermintRPC(), b.checkEthState(), b.checkTxBroadcastLoop(),
+		b.checkLatestCommit(), b.checkTxpoolBacklog(), b.checkSynced(), b.checkTxIntake())
+}
+
+func (b *Backend) checkTendermintRPC() *HealthCheck {
+	check := &HealthCheck{Name: "tendermint_rpc"}
+	if b.client == nil {
+		check.Message = "no tendermint rpc client"
+		return check
+	}
+	if err := b.callTendermint("health", new(ctypes.ResultHealth)); err != nil {
+		check.Message = err.Error()
+		return check
+	}
+	check.Healthy = true
+	return check
+}
+
+func (b *Backend) checkEthState() *HealthCheck {
+	check := &HealthCheck{Name: "eth_state"}
+	commitStarted, lastCommit := b.es.CommitStatus()
+	if !commitStarted.IsZero() && time.Since(commitStarted) > b.healthConfig.MaxCommitDuration {
+		check.Message = fmt.Sprintf("commit running for %v", time.Since(commitStarted).Round(time.Second))
+		return check
+	}
+	check.Healthy = true
+	if !lastCommit.IsZero() {
+		check.Message = fmt.Sprintf("last commit %v ago", time.Since(lastCommit).Round(time.Second))
+	}
+	return check
+}
+
+func (b *Backend) checkTxBroadcastLoop() *HealthCheck {
+	check := &HealthCheck{Name: "tx_broadcast_loop"}
+	switch b.broadcaster.State() {
+	case broadcastNotStarted:
+		check.Message = "not started"
+	case broadcastWaiting:
+		check.Message = "waiting for the tendermint rpc"
+	case broadcastRunning:
+		check.Healthy = true
+	case broadcastStopped:
+		check.Message = "stopped"
+	}
+	return check
+}
+
+func (b *Backend) checkLatestCommit() *HealthCheck {
+	check := &HealthCheck{Name: "latest_commit"}
+	block := b.ethereum.BlockChain().CurrentBlock()
+	age := time.Since(time.Unix(int64(block.Time()), 0)).Round(time.Second)
+	check.Message = fmt.Sprintf("block %v is %v old", block.NumberU64(), age)
+	check.Healthy = b.healthConfig.MaxCommitAge == 0 || age <= b.healthConfig.MaxCommitAge
+	return check
+}
+
+func (b *Backend) checkTxpoolBacklog() *HealthCheck {
+	backlog := b.ethereum.TxPool().GetTxpoolChainHeadSize()
+	return &HealthCheck{
+		Name:    "txpool_backlog",
+		Healthy: backlog <= b.healthConfig.MaxTxpoolBacklog,
+		Message: fmt.Sprintf("%v unread chain head events, threshold %v", backlog, b.healthConfig.MaxTxpoolBacklog),
+	}
+}
+
+func (b *Backend) checkSynced() *HealthCheck {
+	check := &HealthCheck{Name: "synced", Healthy: true}
+	status, err := b.SyncStatus()
+	if err == ErrNoTendermintNode {
+		check.Message = err.Error()
+		return check
+	}
+	if err != nil {
+		check.Healthy = false
+		check.Message = err.Error()
+		return check
+	}
+	if status.CatchingUp {
+		check.Healthy = false
+		check.Message = fmt.Sprintf("catching up, block %v of %v", uint64(status.LatestBlockHeight), uint64(status.HighestBlock))
+	}
+	return check
+}
+
+func (b *Backend) checkTxIntake() *HealthCheck {
+	check := &HealthCheck{Name: "tx_intake", Healthy: true}
+	if err := b.acceptTxs(); err != nil {
+		check.Healthy = false
+		check.Message = err.Error()
+	}
+	return check
+}
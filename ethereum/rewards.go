@@ -0,0 +1,66 @@
+package ethereum
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// recentRewardBlocks is the number of committed blocks the reward history covers
+const recentRewardBlocks = 1000
+
+// BlockReward is the reward paid to a beneficiary in one block
+type BlockReward struct {
+	Height int64    `json:"height"`
+	Amount *big.Int `json:"amount"`
+}
+
+// RecentRewards are the rewards of a beneficiary in the blocks committed since the node started,
+// up to the last recentRewardBlocks
+type RecentRewards struct {
+	FromHeight int64          `json:"from_height"`
+	ToHeight   int64          `json:"to_height"`
+	Total      *big.Int       `json:"total"`
+	Rewards    []*BlockReward `json:"rewards"`
+}
+
+type blockRewards struct {
+	height  int64
+	rewards map[common.Address]*big.Int
+}
+
+// rewardHistory keeps the rewards of the last committed blocks in memory, oldest first
+type rewardHistory struct {
+	mtx    sync.Mutex
+	blocks []blockRewards
+}
+
+func (history *rewardHistory) add(height int64, rewards map[common.Address]*big.Int) {
+	history.mtx.Lock()
+	defer history.mtx.Unlock()
+
+	history.blocks = append(history.blocks, blockRewards{height, rewards})
+	if len(history.blocks) > recentRewardBlocks {
+		history.blocks = history.blocks[len(history.blocks)-recentRewardBlocks:]
+	}
+}
+
+func (history *rewardHistory) rewardsOf(beneficiary common.Address) *RecentRewards {
+	history.mtx.Lock()
+	defer history.mtx.Unlock()
+
+	result := &RecentRewards{Total: big.NewInt(0), Rewards: make([]*BlockReward, 0)}
+	if len(history.blocks) == 0 {
+		return result
+	}
+	result.FromHeight = history.blocks[0].height
+	result.ToHeight = history.blocks[len(history.blocks)-1].height
+	for _, block := range history.blocks {
+		if amount, ok := block.rewards[beneficiary]; ok {
+			result.Rewards = append(result.Rewards, &BlockReward{block.height, new(big.Int).Set(amount)})
+			result.Total.Add(result.Total, amount)
+		}
+	}
+	return result
+}
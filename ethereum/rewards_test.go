@@ -0,0 +1,41 @@
+package ethereum
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewardHistory(t *testing.T) {
+	beneficiary := common.HexToAddress("0x01")
+	other := common.HexToAddress("0x02")
+	history := new(rewardHistory)
+
+	empty := history.rewardsOf(beneficiary)
+	assert.Equal(t, int64(0), empty.Total.Int64())
+	assert.Empty(t, empty.Rewards)
+
+	for height := int64(1); height <= recentRewardBlocks+10; height++ {
+		rewards := map[common.Address]*big.Int{other: big.NewInt(1)}
+		if height%2 == 0 {
+			rewards[beneficiary] = big.NewInt(height)
+		}
+		history.add(height, rewards)
+	}
+
+	// the oldest blocks leave the history
+	recent := history.rewardsOf(beneficiary)
+	assert.Equal(t, int64(11), recent.FromHeight)
+	assert.Equal(t, int64(recentRewardBlocks+10), recent.ToHeight)
+	assert.Len(t, recent.Rewards, recentRewardBlocks/2)
+	assert.Equal(t, int64(12), recent.Rewards[0].Height)
+	total := int64(0)
+	for _, reward := range recent.Rewards {
+		assert.Equal(t, reward.Height, reward.Amount.Int64())
+		total += reward.Height
+	}
+	assert.Equal(t, total, recent.Total.Int64())
+	assert.Equal(t, int64(recentRewardBlocks), history.rewardsOf(other).Total.Int64())
+}
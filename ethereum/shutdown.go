@@ -0,0 +1,23 @@
+package ethereum
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+var errShuttingDown = errors.New("node is shutting down, txs are not accepted")
+
+// StopTxIntake makes dtfn_sendRawTransactions refuse the txs and stops the Broadcaster,
+// the txs queued in the txpool are answered with an error
+func (b *Backend) StopTxIntake() {
+	atomic.StoreInt32(&b.txIntakeStopped, 1)
+	b.broadcaster.Stop()
+}
+
+// acceptTxs returns errShuttingDown once StopTxIntake was called
+func (b *Backend) acceptTxs() error {
+	if atomic.LoadInt32(&b.txIntakeStopped) != 0 {
+		return errShuttingDown
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package ethereum
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+
+	emtTypes "github.com/DTFN/dtfn/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// The slashing journal is kept in the chain db next to the tx hash index,
+// it is not part of the state so it does not change the app hash.
+var slashRecordPrefix = []byte("dtfn-slash-") // slashRecordPrefix + height (uint64 big endian) + index (uint32 big endian) -> json record
+
+func slashRecordKey(height int64, index int) []byte {
+	key := make([]byte, len(slashRecordPrefix)+12)
+	copy(key, slashRecordPrefix)
+	binary.BigEndian.PutUint64(key[len(slashRecordPrefix):], uint64(height))
+	binary.BigEndian.PutUint32(key[len(slashRecordPrefix)+8:], uint32(index))
+	return key
+}
+
+// WriteSlashRecord stores a slashing journal entry
+func WriteSlashRecord(db ethdb.KeyValueWriter, record *emtTypes.SlashRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return db.Put(slashRecordKey(record.Height, record.Index), data)
+}
+
+// ReadSlashRecords returns the journal entries from height fromHeight to toHeight included, oldest first.
+// toHeight <= 0 means up to the latest entry.
+func ReadSlashRecords(db ethdb.Iteratee, fromHeight, toHeight int64) ([]*emtTypes.SlashRecord, error) {
+	if fromHeight < 0 {
+		fromHeight = 0
+	}
+	// the keys are ordered by height, start at the first entry of fromHeight
+	it := db.NewIteratorWithStart(slashRecordKey(fromHeight, 0))
+	defer it.Release()
+
+	records := make([]*emtTypes.SlashRecord, 0)
+	for it.Next() {
+		key := it.Key()
+		if !bytes.HasPrefix(key, slashRecordPrefix) {
+			break
+		}
+		if len(key) != len(slashRecordPrefix)+12 {
+			continue
+		}
+		height := int64(binary.BigEndian.Uint64(key[len(slashRecordPrefix):]))
+		if toHeight > 0 && height > toHeight {
+			break
+		}
+		record := &emtTypes.SlashRecord{}
+		if err := json.Unmarshal(it.Value(), record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, it.Error()
+}
@@ -0,0 +1,58 @@
+package ethereum
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/stretchr/testify/assert"
+
+	emtTypes "github.com/DTFN/dtfn/types"
+)
+
+func TestSlashJournal(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	coinbase := common.HexToAddress("0x01")
+	// heights above 255 check the keys sort by height
+	for _, height := range []int64{3, 7, 256, 300} {
+		for index := 0; index < 2; index++ {
+			record := &emtTypes.SlashRecord{
+				Height:         height,
+				Index:          index,
+				Signer:         common.BigToAddress(big.NewInt(height)),
+				Amount:         big.NewInt(height),
+				Destination:    &coinbase,
+				PosTableAction: emtTypes.SlashActionRemoved,
+			}
+			assert.NoError(t, WriteSlashRecord(db, record))
+		}
+	}
+	// a key sorting right after the journal is not read
+	assert.NoError(t, db.Put(append(append([]byte{}, slashRecordPrefix[:len(slashRecordPrefix)-1]...), '.'), []byte("{")))
+
+	heights := func(records []*emtTypes.SlashRecord) []int64 {
+		result := make([]int64, 0, len(records))
+		for _, record := range records {
+			result = append(result, record.Height)
+		}
+		return result
+	}
+	all, err := ReadSlashRecords(db, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 3, 7, 7, 256, 256, 300, 300}, heights(all))
+	assert.Equal(t, 1, all[1].Index)
+	assert.Equal(t, &coinbase, all[0].Destination)
+
+	records, err := ReadSlashRecords(db, 7, 256)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{7, 7, 256, 256}, heights(records))
+
+	records, err = ReadSlashRecords(db, 4, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{7, 7, 256, 256, 300, 300}, heights(records))
+
+	records, err = ReadSlashRecords(db, 301, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+}
@@ -0,0 +1,196 @@
+package ethereum
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	cs "github.com/tendermint/tendermint/consensus"
+	tmNode "github.com/tendermint/tendermint/node"
+	"github.com/tendermint/tendermint/p2p"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmTypes "github.com/tendermint/tendermint/types"
+)
+
+// maxSyncLag is how many blocks the node may be behind its highest peer and still count as caught up
+const maxSyncLag = 2
+
+// SyncStatus is the tendermint view of the node, as reported by the tendermint /status endpoint
+type SyncStatus struct {
+	NodeID            string         `json:"nodeId"`
+	Moniker           string         `json:"moniker"`
+	Network           string         `json:"network"`
+	Listening         bool           `json:"listening"`
+	Peers             int            `json:"peers"`
+	CatchingUp        bool           `json:"catchingUp"`
+	FastSync          bool           `json:"fastSync"`
+	StartingBlock     hexutil.Uint64 `json:"startingBlock"`
+	HighestBlock      hexutil.Uint64 `json:"highestBlock"`
+	LatestBlockHeight hexutil.Uint64 `json:"latestBlockHeight"`
+	LatestBlockHash   hexutil.Bytes  `json:"latestBlockHash"`
+	LatestAppHash     hexutil.Bytes  `json:"latestAppHash"`
+	LatestBlockTime   time.Time      `json:"latestBlockTime"`
+}
+
+// SyncProgress is the block sync progress in the eth_syncing format
+type SyncProgress struct {
+	StartingBlock hexutil.Uint64 `json:"startingBlock"`
+	CurrentBlock  hexutil.Uint64 `json:"currentBlock"`
+	HighestBlock  hexutil.Uint64 `json:"highestBlock"`
+}
+
+// SetTendermintNode sets the tendermint node the rpc reports the peers and the sync status of.
+// It stays nil when the app is run behind an external tendermint.
+func (b *Backend) SetTendermintNode(node *tmNode.Node) {
+	b.tmNode = node
+	atomic.StoreInt64(&b.startingBlock, node.BlockStore().Height())
+}
+
+// TendermintNode returns the in-process tendermint node, nil if there is none
+func (b *Backend) TendermintNode() *tmNode.Node {
+	return b.tmNode
+}
+
+// PeerCount returns the number of tendermint peers, 0 without an in-process tendermint
+func (b *Backend) PeerCount() int {
+	if b.tmNode == nil {
+		return 0
+	}
+	return b.tmNode.Switch().Peers().Size()
+}
+
+// IsListening tells if the tendermint p2p switch accepts connections, true without an in-process tendermint
+func (b *Backend) IsListening() bool {
+	if b.tmNode == nil {
+		return true
+	}
+	return b.tmNode.Switch().IsListening()
+}
+
+// highestPeerBlock is the highest block committed by a peer, as seen by the consensus reactor.
+// The peers report the height they work on, one above their last block.
+func highestPeerBlock(peers []p2p.Peer) int64 {
+	highest := int64(0)
+	for _, peer := range peers {
+		peerState, ok := peer.Get(tmTypes.PeerStateKey).(*cs.PeerState)
+		if !ok {
+			continue
+		}
+		if height := peerState.GetHeight() - 1; height > highest {
+			highest = height
+		}
+	}
+	return highest
+}
+
+// catchingUp tells if a node at height is catching up: while tendermint fast syncs
+// or while it is more than maxSyncLag blocks behind the highest block of its peers
+func catchingUp(fastSync bool, height int64, highest int64) bool {
+	return fastSync || highest-height > maxSyncLag
+}
+
+// callTendermint calls method of the tendermint rpc without params, giving up after tendermintRPCTimeout
+func (b *Backend) callTendermint(method string, result interface{}) error {
+	registerAmino(b.client.Codec())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := b.client.Call(method, map[string]interface{}{}, result)
+		errCh <- err
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(tendermintRPCTimeout):
+		return fmt.Errorf("tendermint %v: no answer after %v", method, tendermintRPCTimeout)
+	}
+}
+
+// SyncStatus returns the tendermint sync status of the in-process tendermint, or else the one
+// the tendermint rpc reports. It fails with ErrNoTendermintNode without either of them.
+func (b *Backend) SyncStatus() (*SyncStatus, error) {
+	if b.tmNode != nil {
+		return b.localSyncStatus(), nil
+	}
+	if b.client != nil {
+		return b.remoteSyncStatus()
+	}
+	return nil, ErrNoTendermintNode
+}
+
+// localSyncStatus is the status of the in-process tendermint
+func (b *Backend) localSyncStatus() *SyncStatus {
+	node := b.tmNode
+	nodeInfo := node.Switch().NodeInfo()
+	height := node.BlockStore().Height()
+	highest := highestPeerBlock(node.Switch().Peers().List())
+	if highest < height {
+		highest = height
+	}
+	fastSync := node.ConsensusReactor().FastSync()
+	status := &SyncStatus{
+		Listening:         node.Switch().IsListening(),
+		Peers:             node.Switch().Peers().Size(),
+		CatchingUp:        catchingUp(fastSync, height, highest),
+		FastSync:          fastSync,
+		StartingBlock:     hexutil.Uint64(atomic.LoadInt64(&b.startingBlock)),
+		HighestBlock:      hexutil.Uint64(highest),
+		LatestBlockHeight: hexutil.Uint64(height),
+	}
+	status.NodeID = string(nodeInfo.ID())
+	if defaultInfo, ok := nodeInfo.(p2p.DefaultNodeInfo); ok {
+		status.Moniker = defaultInfo.Moniker
+		status.Network = defaultInfo.Network
+	}
+	if meta := node.BlockStore().LoadBlockMeta(height); meta != nil {
+		status.LatestBlockHash = hexutil.Bytes(meta.BlockID.Hash)
+		status.LatestAppHash = hexutil.Bytes(meta.Header.AppHash)
+		status.LatestBlockTime = meta.Header.Time
+	}
+	return status
+}
+
+// remoteSyncStatus is the status of an external tendermint, read from its rpc. The tendermint status
+// has neither the peers nor their heights, the node is catching up while tendermint fast syncs.
+// The starting block is the height of the first status read.
+func (b *Backend) remoteSyncStatus() (*SyncStatus, error) {
+	result := new(ctypes.ResultStatus)
+	if err := b.callTendermint("status", result); err != nil {
+		return nil, err
+	}
+	height := result.SyncInfo.LatestBlockHeight
+	atomic.CompareAndSwapInt64(&b.startingBlock, 0, height)
+	return &SyncStatus{
+		NodeID:            string(result.NodeInfo.ID()),
+		Moniker:           result.NodeInfo.Moniker,
+		Network:           result.NodeInfo.Network,
+		Listening:         true,
+		CatchingUp:        result.SyncInfo.CatchingUp,
+		FastSync:          result.SyncInfo.CatchingUp,
+		StartingBlock:     hexutil.Uint64(atomic.LoadInt64(&b.startingBlock)),
+		HighestBlock:      hexutil.Uint64(height),
+		LatestBlockHeight: hexutil.Uint64(height),
+		LatestBlockHash:   hexutil.Bytes(result.SyncInfo.LatestBlockHash),
+		LatestAppHash:     hexutil.Bytes(result.SyncInfo.LatestAppHash),
+		LatestBlockTime:   result.SyncInfo.LatestBlockTime,
+	}, nil
+}
+
+// SyncProgress returns the block sync progress, nil once the node caught up or without tendermint
+func (b *Backend) SyncProgress() (*SyncProgress, error) {
+	status, err := b.SyncStatus()
+	if err == ErrNoTendermintNode {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !status.CatchingUp {
+		return nil, nil
+	}
+	return &SyncProgress{
+		StartingBlock: status.StartingBlock,
+		CurrentBlock:  status.LatestBlockHeight,
+		HighestBlock:  status.HighestBlock,
+	}, nil
+}
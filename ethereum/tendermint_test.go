@@ -0,0 +1,103 @@
+package ethereum
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	amino "github.com/tendermint/go-amino"
+	cs "github.com/tendermint/tendermint/consensus"
+	"github.com/tendermint/tendermint/p2p"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpcClient "github.com/tendermint/tendermint/rpc/lib/client"
+	tmTypes "github.com/tendermint/tendermint/types"
+)
+
+// statePeer is a peer holding the consensus state the reactor sets on it, if any
+type statePeer struct {
+	p2p.Peer
+	peerState *cs.PeerState
+}
+
+func (peer *statePeer) Get(key string) interface{} {
+	if key != tmTypes.PeerStateKey || peer.peerState == nil {
+		return nil
+	}
+	return peer.peerState
+}
+
+func newStatePeer(height int64) *statePeer {
+	peer := &statePeer{}
+	peer.peerState = cs.NewPeerState(peer)
+	peer.peerState.PRS.Height = height
+	return peer
+}
+
+func TestHighestPeerBlock(t *testing.T) {
+	assert.Equal(t, int64(0), highestPeerBlock(nil))
+	// the peers work on the height above their last block, a peer without state is skipped
+	peers := []p2p.Peer{newStatePeer(8), &statePeer{}, newStatePeer(11), newStatePeer(5)}
+	assert.Equal(t, int64(10), highestPeerBlock(peers))
+}
+
+func TestCatchingUp(t *testing.T) {
+	assert.False(t, catchingUp(false, 10, 10))
+	assert.False(t, catchingUp(false, 10, 10+maxSyncLag))
+	assert.True(t, catchingUp(false, 10, 11+maxSyncLag))
+	assert.True(t, catchingUp(true, 10, 10))
+}
+
+// statusServer answers the tendermint status with catchingUp and height
+func statusServer(t *testing.T, catchingUp bool, height int64) *httptest.Server {
+	cdc := amino.NewCodec()
+	ctypes.RegisterAmino(cdc)
+	result, err := cdc.MarshalJSON(&ctypes.ResultStatus{
+		SyncInfo: ctypes.SyncInfo{LatestBlockHeight: height, CatchingUp: catchingUp},
+	})
+	assert.NoError(t, err)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":-1,"result":%s}`, result)
+	}))
+}
+
+func TestRemoteSyncStatus(t *testing.T) {
+	_, err := (&Backend{}).SyncStatus()
+	assert.Equal(t, ErrNoTendermintNode, err)
+	progress, err := (&Backend{}).SyncProgress()
+	assert.NoError(t, err)
+	assert.Nil(t, progress)
+
+	server := statusServer(t, true, 7)
+	defer server.Close()
+	backend := &Backend{client: rpcClient.NewURIClient(server.URL)}
+	status, err := backend.SyncStatus()
+	assert.NoError(t, err)
+	assert.True(t, status.CatchingUp)
+	assert.Equal(t, uint64(7), uint64(status.LatestBlockHeight))
+	assert.Equal(t, uint64(7), uint64(status.StartingBlock))
+	progress, err = backend.SyncProgress()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), uint64(progress.CurrentBlock))
+	assert.False(t, backend.checkSynced().Healthy)
+
+	synced := statusServer(t, false, 9)
+	defer synced.Close()
+	backend.client = rpcClient.NewURIClient(synced.URL)
+	status, err = backend.SyncStatus()
+	assert.NoError(t, err)
+	assert.False(t, status.CatchingUp)
+	// the starting block is the height of the first status
+	assert.Equal(t, uint64(7), uint64(status.StartingBlock))
+	progress, err = backend.SyncProgress()
+	assert.NoError(t, err)
+	assert.Nil(t, progress)
+	assert.True(t, backend.checkSynced().Healthy)
+
+	// an unreachable tendermint is not synced
+	synced.Close()
+	_, err = backend.SyncStatus()
+	assert.Error(t, err)
+	assert.False(t, backend.checkSynced().Healthy)
+}
@@ -0,0 +1,54 @@
+package ethereum
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// Tendermint identifies a tx by the sha256 of its bytes, ethereum by the
+// keccak256 of its rlp. We keep both directions in the chain db so a tx can
+// be looked up with either hash.
+var (
+	tmTxHashPrefix  = []byte("dtfn-tm-tx-")  // tmTxHashPrefix + tm hash -> eth hash
+	ethTxHashPrefix = []byte("dtfn-eth-tx-") // ethTxHashPrefix + eth hash -> tm hash
+)
+
+// txHashMapping links the tendermint and the ethereum hash of a delivered tx
+type txHashMapping struct {
+	tmHash  []byte
+	ethHash common.Hash
+}
+
+func tmTxHashKey(tmHash []byte) []byte {
+	return append(append([]byte{}, tmTxHashPrefix...), tmHash...)
+}
+
+func ethTxHashKey(ethHash common.Hash) []byte {
+	return append(append([]byte{}, ethTxHashPrefix...), ethHash.Bytes()...)
+}
+
+// WriteTxHashMapping stores the mapping between the tendermint and the ethereum hash of a tx
+func WriteTxHashMapping(db ethdb.KeyValueWriter, tmHash []byte, ethHash common.Hash) error {
+	if err := db.Put(tmTxHashKey(tmHash), ethHash.Bytes()); err != nil {
+		return err
+	}
+	return db.Put(ethTxHashKey(ethHash), tmHash)
+}
+
+// ReadEthTxHash returns the ethereum hash of the tx with the given tendermint hash
+func ReadEthTxHash(db ethdb.KeyValueReader, tmHash []byte) (common.Hash, bool) {
+	data, err := db.Get(tmTxHashKey(tmHash))
+	if err != nil || len(data) != common.HashLength {
+		return common.Hash{}, false
+	}
+	return common.BytesToHash(data), true
+}
+
+// ReadTmTxHash returns the tendermint hash of the tx with the given ethereum hash
+func ReadTmTxHash(db ethdb.KeyValueReader, ethHash common.Hash) ([]byte, bool) {
+	data, err := db.Get(ethTxHashKey(ethHash))
+	if err != nil || len(data) == 0 {
+		return nil, false
+	}
+	return data, true
+}
@@ -0,0 +1,33 @@
+package ethereum
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxHashMapping(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	tmHash := sha256.Sum256([]byte("tx"))
+	ethHash := common.HexToHash("0xabcdef")
+
+	_, ok := ReadEthTxHash(db, tmHash[:])
+	assert.False(t, ok)
+	_, ok = ReadTmTxHash(db, ethHash)
+	assert.False(t, ok)
+
+	assert.NoError(t, WriteTxHashMapping(db, tmHash[:], ethHash))
+	mapped, ok := ReadEthTxHash(db, tmHash[:])
+	assert.True(t, ok)
+	assert.Equal(t, ethHash, mapped)
+	mappedTm, ok := ReadTmTxHash(db, ethHash)
+	assert.True(t, ok)
+	assert.Equal(t, tmHash[:], mappedTm)
+
+	// an eth hash is not taken for a tm hash
+	_, ok = ReadEthTxHash(db, ethHash.Bytes())
+	assert.False(t, ok)
+}
@@ -0,0 +1,133 @@
+package ethereum
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// DefaultTxStatusRetention is how long the status of a tx is kept after its last transition
+	DefaultTxStatusRetention = 10 * time.Minute
+	// maxTrackedTxs bounds the memory of the tracker, the oldest statuses go first
+	maxTrackedTxs = 200000
+)
+
+// TxStage is a step of the lifecycle of a tx
+type TxStage string
+
+const (
+	// TxStageTxpoolRejected: the txpool accepted the tx from the rpc but dropped it before the CheckTx,
+	// e.g. the node is shutting down. The errors of the txpool validation are returned to the rpc caller.
+	TxStageTxpoolRejected TxStage = "txpool_rejected"
+	// TxStageCheckTxFailed: tendermint or the app refused the tx in CheckTx
+	TxStageCheckTxFailed TxStage = "checktx_failed"
+	// TxStageMempool: the tx passed CheckTx and waits in the tendermint mempool
+	TxStageMempool TxStage = "mempool"
+	// TxStageEvicted: the tx failed the recheck after a block and left the tendermint mempool
+	TxStageEvicted TxStage = "evicted"
+	// TxStageDeliverTxFailed: the tx was included in a block and failed
+	TxStageDeliverTxFailed TxStage = "delivertx_failed"
+	// TxStageDelivered: the tx was included in a block and succeeded
+	TxStageDelivered TxStage = "delivered"
+)
+
+// TxStageEvent is one transition of a tx
+type TxStageEvent struct {
+	Stage  TxStage   `json:"stage"`
+	Reason string    `json:"reason,omitempty"`
+	Height int64     `json:"height,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// TxStatus is the lifecycle of a tx seen by this node, the last event is the current stage
+type TxStatus struct {
+	Hash   common.Hash     `json:"hash"`
+	Stage  TxStage         `json:"stage"`
+	Events []*TxStageEvent `json:"events"`
+}
+
+type trackedTx struct {
+	hash    common.Hash
+	updated time.Time
+}
+
+// TxTracker records the stage transitions of the txs by eth hash for a retention window
+type TxTracker struct {
+	mtx       sync.Mutex
+	retention time.Duration
+	statuses  map[common.Hash]*TxStatus
+	// every transition in time order, an entry expires the status only if it was its last one
+	queue []trackedTx
+}
+
+// NewTxTracker creates a tracker keeping the statuses for retention after their last transition
+func NewTxTracker(retention time.Duration) *TxTracker {
+	return &TxTracker{
+		retention: retention,
+		statuses:  make(map[common.Hash]*TxStatus),
+	}
+}
+
+// SetRetention sets how long the statuses are kept after their last transition
+func (tracker *TxTracker) SetRetention(retention time.Duration) {
+	tracker.mtx.Lock()
+	defer tracker.mtx.Unlock()
+
+	tracker.retention = retention
+}
+
+// Record appends a transition to the status of the tx
+func (tracker *TxTracker) Record(hash common.Hash, stage TxStage, reason string, height int64) {
+	tracker.mtx.Lock()
+	defer tracker.mtx.Unlock()
+
+	now := time.Now()
+	status, ok := tracker.statuses[hash]
+	if !ok {
+		status = &TxStatus{Hash: hash}
+		tracker.statuses[hash] = status
+	}
+	status.Stage = stage
+	status.Events = append(status.Events, &TxStageEvent{Stage: stage, Reason: reason, Height: height, Time: now})
+	tracker.queue = append(tracker.queue, trackedTx{hash, now})
+	tracker.prune(now)
+}
+
+// Status returns a copy of the status of the tx, false if it is unknown or expired
+func (tracker *TxTracker) Status(hash common.Hash) (*TxStatus, bool) {
+	tracker.mtx.Lock()
+	defer tracker.mtx.Unlock()
+
+	tracker.prune(time.Now())
+	status, ok := tracker.statuses[hash]
+	if !ok {
+		return nil, false
+	}
+	events := make([]*TxStageEvent, len(status.Events))
+	for i, event := range status.Events {
+		eventCopy := *event
+		events[i] = &eventCopy
+	}
+	return &TxStatus{Hash: status.Hash, Stage: status.Stage, Events: events}, true
+}
+
+// prune drops the statuses whose last transition is older than the retention,
+// and the oldest ones over maxTrackedTxs
+func (tracker *TxTracker) prune(now time.Time) {
+	cutoff := now.Add(-tracker.retention)
+	dropped := 0
+	for _, entry := range tracker.queue {
+		if !entry.updated.Before(cutoff) && len(tracker.statuses) <= maxTrackedTxs {
+			break
+		}
+		dropped++
+		status, ok := tracker.statuses[entry.hash]
+		if ok && !status.Events[len(status.Events)-1].Time.After(entry.updated) {
+			delete(tracker.statuses, entry.hash)
+		}
+	}
+	// the next append that outgrows the backing array copies the live entries only
+	tracker.queue = tracker.queue[dropped:]
+}
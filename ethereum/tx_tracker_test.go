@@ -0,0 +1,54 @@
+package ethereum
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxTrackerStages(t *testing.T) {
+	tracker := NewTxTracker(time.Minute)
+	hash := common.HexToHash("0x01")
+
+	_, ok := tracker.Status(hash)
+	assert.False(t, ok)
+
+	tracker.Record(hash, TxStageMempool, "", 0)
+	tracker.Record(hash, TxStageDeliverTxFailed, "code 1: out of gas", 10)
+	status, ok := tracker.Status(hash)
+	assert.True(t, ok)
+	assert.Equal(t, hash, status.Hash)
+	assert.Equal(t, TxStageDeliverTxFailed, status.Stage)
+	assert.Len(t, status.Events, 2)
+	assert.Equal(t, TxStageMempool, status.Events[0].Stage)
+	assert.Equal(t, "code 1: out of gas", status.Events[1].Reason)
+	assert.Equal(t, int64(10), status.Events[1].Height)
+
+	// the returned status is a copy
+	status.Events[0].Reason = "changed"
+	status, _ = tracker.Status(hash)
+	assert.Equal(t, "", status.Events[0].Reason)
+}
+
+func TestTxTrackerRetention(t *testing.T) {
+	tracker := NewTxTracker(100 * time.Millisecond)
+	kept := common.HexToHash("0x01")
+	expired := common.HexToHash("0x02")
+
+	tracker.Record(kept, TxStageMempool, "", 0)
+	tracker.Record(expired, TxStageMempool, "", 0)
+	time.Sleep(60 * time.Millisecond)
+	// a new stage renews the retention
+	tracker.Record(kept, TxStageDelivered, "", 1)
+	time.Sleep(60 * time.Millisecond)
+
+	_, ok := tracker.Status(expired)
+	assert.False(t, ok)
+	status, ok := tracker.Status(kept)
+	assert.True(t, ok)
+	assert.Equal(t, TxStageDelivered, status.Stage)
+	// only the last transition of kept is queued
+	assert.Len(t, tracker.queue, 1)
+}
@@ -1,56 +1,19 @@
 package ethereum
 
 import (
-	"time"
-
 	"fmt"
-	"github.com/ethereum/go-ethereum/core"
 	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
 	abciTypes "github.com/tendermint/tendermint/abci/types"
 	ctypes "github.com/tendermint/tendermint/rpc/core/types"
-	rpcClient "github.com/tendermint/tendermint/rpc/lib/client"
 	tmTypes "github.com/tendermint/tendermint/types"
 )
 
 //----------------------------------------------------------------------
-// Transactions sent via the go-ethereum rpc need to be routed to tendermint
-
-// listen for txs and forward to tendermint
-func (b *Backend) txBroadcastLoop() {
-	//b.txSub = b.ethereum.EventMux().Subscribe(core.TxPreEvent{})
-	ch := make(chan core.TxPreEvent, 50000)
-	sub := b.ethereum.TxPool().SubscribeTxPreEvent(ch)
-	defer close(ch)
-	defer sub.Unsubscribe()
-
-	waitForServer(b.client)
-	b.ethereum.TxPool().HandleJournalTxs()
-	//for obj := range b.txSub.Chan() {
-	txCount := 0
-	for obj := range ch {
-		b.currentTxInfo = ethTypes.TxInfo{
-			Tx:        obj.Tx,
-			From:      obj.From,
-			RelayFrom: obj.Relayer,
-			SubTx:     obj.SubTx,
-		}
-
-		if err := b.BroadcastTx(obj.Tx); err != nil {
-			log.Error("Broadcast error", "err", err)
-			obj.Result <- err
-			go b.ethereum.TxPool().RemoveTx(obj.Tx.Hash()) //start a goroutine to avoid deadlock
-		} else {
-			obj.Result <- nil
-		}
-		if txCount > 1<<10 {
-			b.ethereum.TxPool().SetFlowLimit(b.memPool.Size())
-			txCount = 0
-		}
-		txCount++
-	}
-}
+// Transactions sent via the go-ethereum rpc need to be routed to tendermint,
+// the Broadcaster listens for them and calls BroadcastTx
 
 func (b *Backend) BroadcastTxSync(tx tmTypes.Tx) (*ctypes.ResultBroadcastTx, error) {
 	resCh := make(chan *abciTypes.Response, 1)
@@ -58,7 +21,9 @@ func (b *Backend) BroadcastTxSync(tx tmTypes.Tx) (*ctypes.ResultBroadcastTx, err
 		resCh <- res
 	})
 	if err != nil {
-		return nil, fmt.Errorf("Error broadcasting transaction: %v ", err)
+		// the app did not see the tx, the mempool refused it
+		b.txTracker.Record(crypto.Keccak256Hash(tx), TxStageCheckTxFailed, err.Error(), 0)
+		return nil, fmt.Errorf("Error broadcasting transaction: %w", err)
 	}
 	res := <-resCh
 	r := res.GetCheckTx()
@@ -98,21 +63,3 @@ func (b *Backend) BroadcastTx(tx *ethTypes.Transaction) error {
 	}
 	return nil
 }
-
-//----------------------------------------------------------------------
-// wait for Tendermint to open the socket and run http endpoint
-
-func waitForServer(c rpcClient.HTTPClient) {
-	ctypes.RegisterAmino(c.Codec())
-	result := new(ctypes.ResultStatus)
-
-	for {
-		_, err := c.Call("status", map[string]interface{}{}, result)
-		if err == nil {
-			break
-		}
-
-		log.Info("Waiting for tendermint endpoint to start", "err", err)
-		time.Sleep(time.Second * 3)
-	}
-}
@@ -3,6 +3,8 @@ package httpserver
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/txfilter"
 	"github.com/DTFN/dtfn/ethereum"
 	emtTypes "github.com/DTFN/dtfn/types"
@@ -11,6 +13,12 @@ import (
 	"math/big"
 	"net/http"
 	"strconv"
+	"strings"
+)
+
+const (
+	txStatusPrefix = "/v2/tx/"
+	txStatusSuffix = "/status"
 )
 
 type THandler struct {
@@ -48,12 +56,27 @@ func (tHandler *THandler) RegisterFunc() {
 	//tHandler.HandlersMap["/GetNextAllCandidateValidators"] = tHandler.GetNextAllCandidateValidatorPool
 	tHandler.HandlersMap["/GetInitialValidator"] = tHandler.GetInitialValidator
 	tHandler.HandlersMap["/GetHeadEventSize"] = tHandler.GetTxPoolEventSize
+	tHandler.HandlersMap["/tx"] = tHandler.GetTx
+	tHandler.HandlersMap["/GetTxPolicy"] = tHandler.GetTxPolicy
+	tHandler.HandlersMap["/GetAdminSet"] = tHandler.GetAdminSet
+	tHandler.HandlersMap["/GetAdminProposals"] = tHandler.GetAdminProposals
+	tHandler.HandlersMap["/GetAdminRegistry"] = tHandler.GetAdminRegistry
+	tHandler.HandlersMap["/v2/slashes"] = tHandler.GetSlashes
+	tHandler.HandlersMap["/GetValidatorStatus"] = tHandler.GetValidatorStatus
+	tHandler.HandlersMap["/v2/syncing"] = tHandler.GetSyncing
+	tHandler.HandlersMap["/healthz"] = tHandler.Healthz
+	tHandler.HandlersMap["/readyz"] = tHandler.Readyz
 	//tHandler.HandlersMap["/GetAuthTable"] = tHandler.GetAuthTable
 }
 
 func (tHandler *THandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if h, ok := tHandler.HandlersMap[r.URL.String()]; ok {
+	if h, ok := tHandler.HandlersMap[r.URL.Path]; ok {
 		h(w, r)
+		return
+	}
+	// paths carrying a parameter, /v2/tx/{hash}/status
+	if strings.HasPrefix(r.URL.Path, txStatusPrefix) && strings.HasSuffix(r.URL.Path, txStatusSuffix) {
+		tHandler.GetTxStatus(w, r)
 	}
 }
 
@@ -130,13 +153,22 @@ func (tHandler *THandler) GetAccountMapData(w http.ResponseWriter, req *http.Req
 		if !ok {
 			panic(fmt.Sprintf("TmAddressToSignerMap and PosItemMap mismatch in tmAddress %v signer %X", tmAddress, signer))
 		}
-		AccountMap.MapList[tmAddress] = AccountBean{
+		accountBean := AccountBean{
 			Signer:           signer.String(),
 			Slots:            posItem.Slots,
 			BeneficiaryBonus: posItem.BeneficiaryBonus.Int64(),
 			Beneficiary:      posItem.Beneficiary.String(),
 			BlsKeyString:     posItem.BlsKeyString,
 		}
+		if nextPosItem, ok := tHandler.strategy.NextEpochValData.PosTable.PosItemMap[signer]; ok {
+			if nextPosItem.Beneficiary != posItem.Beneficiary {
+				accountBean.NextBeneficiary = nextPosItem.Beneficiary.String()
+			}
+			if nextPosItem.BlsKeyString != posItem.BlsKeyString {
+				accountBean.NextBlsKeyString = nextPosItem.BlsKeyString
+			}
+		}
+		AccountMap.MapList[tmAddress] = accountBean
 	}
 	jsonStr, err := json.Marshal(AccountMap)
 	if err != nil {
@@ -322,3 +354,217 @@ func (tHandler *THandler) GetAuthTable(w http.ResponseWriter, req *http.Request)
 		w.Write(jsonStr)
 	}
 }
+
+// GetTx returns a committed tx, /tx?hash=0x... accepts the ethereum or the tendermint hash
+func (tHandler *THandler) GetTx(w http.ResponseWriter, req *http.Request) {
+	hashBytes, err := hexutil.Decode(req.URL.Query().Get("hash"))
+	if err != nil || len(hashBytes) != common.HashLength {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("hash should be a 0x prefixed 32 bytes hex string"))
+		return
+	}
+	lookup, err := tHandler.backend.LookupTransaction(common.BytesToHash(hashBytes))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	jsonStr, err := json.Marshal(lookup)
+	if err != nil {
+		w.Write([]byte("error occurred when marshal into json"))
+	} else {
+		w.Write(jsonStr)
+	}
+}
+
+// GetTxPolicy returns the contract creation, contract call and value allow-lists
+func (tHandler *THandler) GetTxPolicy(w http.ResponseWriter, req *http.Request) {
+	jsonStr, err := json.Marshal(tHandler.strategy.TxPolicy.Copy())
+	if err != nil {
+		w.Write([]byte("error occured when marshal into json"))
+	} else {
+		w.Write(jsonStr)
+	}
+}
+
+// GetAdminSet returns the M-of-N admin set with its pending proposals
+func (tHandler *THandler) GetAdminSet(w http.ResponseWriter, req *http.Request) {
+	jsonStr, err := json.Marshal(tHandler.strategy.AdminSet.Copy())
+	if err != nil {
+		w.Write([]byte("error occured when marshal into json"))
+	} else {
+		w.Write(jsonStr)
+	}
+}
+
+// GetAdminProposals returns the proposals waiting for the approval of the admin set
+func (tHandler *THandler) GetAdminProposals(w http.ResponseWriter, req *http.Request) {
+	jsonStr, err := json.Marshal(tHandler.strategy.AdminSet.Copy().Proposals)
+	if err != nil {
+		w.Write([]byte("error occured when marshal into json"))
+	} else {
+		w.Write(jsonStr)
+	}
+}
+
+// GetAdminRegistry returns the rotated admin accounts with the pending rotations and the history
+func (tHandler *THandler) GetAdminRegistry(w http.ResponseWriter, req *http.Request) {
+	jsonStr, err := json.Marshal(tHandler.strategy.AdminRegistry.Copy())
+	if err != nil {
+		w.Write([]byte("error occured when marshal into json"))
+	} else {
+		w.Write(jsonStr)
+	}
+}
+
+// GetSlashes returns the slashing journal, optionally limited by the from and to heights
+func (tHandler *THandler) GetSlashes(w http.ResponseWriter, req *http.Request) {
+	var fromHeight, toHeight int64
+	var err error
+	if from := req.URL.Query().Get("from"); from != "" {
+		if fromHeight, err = strconv.ParseInt(from, 10, 64); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("invalid from height: %v", err)))
+			return
+		}
+	}
+	if to := req.URL.Query().Get("to"); to != "" {
+		if toHeight, err = strconv.ParseInt(to, 10, 64); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("invalid to height: %v", err)))
+			return
+		}
+	}
+	slashes, err := tHandler.backend.SlashRecords(fromHeight, toHeight)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	jsonStr, err := json.Marshal(slashes)
+	if err != nil {
+		w.Write([]byte("error occured when marshal into json"))
+	} else {
+		w.Write(jsonStr)
+	}
+}
+
+// GetValidatorStatus returns the PosItems of a signer in the current and the next epoch,
+// its unbonding item if any, the rewards of its beneficiary in the last blocks and its balance
+func (tHandler *THandler) GetValidatorStatus(w http.ResponseWriter, req *http.Request) {
+	signerHex := req.URL.Query().Get("signer")
+	if !common.IsHexAddress(signerHex) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid signer"))
+		return
+	}
+	signer := common.HexToAddress(signerHex)
+	status := &ValidatorStatus{Signer: signer}
+	// copies taken under the PosTable locks, the app keeps changing the tables
+	currPosTable := tHandler.strategy.CurrEpochValData.PosTable.Copy()
+	nextPosTable := tHandler.strategy.NextEpochValData.PosTable.Copy()
+	if posItem, ok := currPosTable.PosItemMap[signer]; ok {
+		status.Current = posItem
+	}
+	if posItem, ok := nextPosTable.PosItemMap[signer]; ok {
+		status.Next = posItem
+	}
+	if posItem, ok := nextPosTable.UnbondPosItemMap[signer]; ok {
+		status.Unbond = posItem
+	}
+	if status.Current == nil && status.Next == nil && status.Unbond == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("signer not found in the PosTable"))
+		return
+	}
+	for _, posItem := range []*txfilter.PosItem{status.Next, status.Current, status.Unbond} {
+		if posItem != nil {
+			status.Beneficiary = posItem.Beneficiary
+			break
+		}
+	}
+	status.RecentRewards = tHandler.backend.RecentRewards(status.Beneficiary)
+	if state, err := tHandler.backend.Ethereum().BlockChain().State(); err == nil {
+		status.BeneficiaryBalance = state.GetBalance(status.Beneficiary)
+	}
+	jsonStr, err := json.Marshal(status)
+	if err != nil {
+		w.Write([]byte("error occured when marshal into json"))
+	} else {
+		w.Write(jsonStr)
+	}
+}
+
+// GetSyncing returns the tendermint sync status, with 503 while the node catches up
+// so the load balancers keep it out of the rotation
+func (tHandler *THandler) GetSyncing(w http.ResponseWriter, req *http.Request) {
+	status, err := tHandler.backend.SyncStatus()
+	if err != nil {
+		if err == ethereum.ErrNoTendermintNode {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write([]byte(err.Error()))
+		return
+	}
+	jsonStr, err := json.Marshal(status)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("error occured when marshal into json"))
+		return
+	}
+	if status.CatchingUp {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(jsonStr)
+}
+
+// Healthz reports the liveness checks, with 503 if one fails
+func (tHandler *THandler) Healthz(w http.ResponseWriter, req *http.Request) {
+	writeHealthReport(w, tHandler.backend.Liveness())
+}
+
+// Readyz reports the readiness checks, with 503 if one fails
+func (tHandler *THandler) Readyz(w http.ResponseWriter, req *http.Request) {
+	writeHealthReport(w, tHandler.backend.Readiness())
+}
+
+func writeHealthReport(w http.ResponseWriter, report *ethereum.HealthReport) {
+	jsonStr, err := json.Marshal(report)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("error occured when marshal into json"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !report.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(jsonStr)
+}
+
+// GetTxStatus returns the stages a tx went through on this node, the path is /v2/tx/{hash}/status
+func (tHandler *THandler) GetTxStatus(w http.ResponseWriter, req *http.Request) {
+	hashHex := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, txStatusPrefix), txStatusSuffix)
+	hash, err := hexutil.Decode(hashHex)
+	if err != nil || len(hash) != common.HashLength {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid tx hash"))
+		return
+	}
+	status, ok := tHandler.backend.TxTracker().Status(common.BytesToHash(hash))
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("tx not seen in the retention window"))
+		return
+	}
+	jsonStr, err := json.Marshal(status)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("error occured when marshal into json"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonStr)
+}
@@ -5,6 +5,8 @@ import (
 	abciTypes "github.com/tendermint/tendermint/abci/types"
 	"math/big"
 	"github.com/ethereum/go-ethereum/core/txfilter"
+
+	"github.com/DTFN/dtfn/ethereum"
 )
 
 type Validator struct {
@@ -38,6 +40,9 @@ type AccountBean struct {
 	BeneficiaryBonus int64  `json:"beneficiaryBonus"`
 	Beneficiary      string `json:"beneficiary"`
 	BlsKeyString     string `json:"blsKeyString"`
+	// pending updates taking effect at the next epoch
+	NextBeneficiary  string `json:"nextBeneficiary,omitempty"`
+	NextBlsKeyString string `json:"nextBlsKeyString,omitempty"`
 }
 
 type PosItemMapData struct {
@@ -60,3 +65,13 @@ type Encourage struct {
 	TotalBalance          *big.Int `json:"initialTotalBalance"`
 	EncourageAverageBlock *big.Int `json:"encourageAverageBlock"`
 }
+
+type ValidatorStatus struct {
+	Signer             common.Address          `json:"signer"`
+	Current            *txfilter.PosItem       `json:"current,omitempty"`
+	Next               *txfilter.PosItem       `json:"next,omitempty"`
+	Unbond             *txfilter.PosItem       `json:"unbond,omitempty"`
+	Beneficiary        common.Address          `json:"beneficiary"`
+	RecentRewards      *ethereum.RecentRewards `json:"recent_rewards"`
+	BeneficiaryBalance *big.Int                `json:"beneficiary_balance"`
+}
@@ -6,7 +6,6 @@ ROOT_DIR=$(cd `dirname $(readlink -f "$0")`/.. && pwd)
 OS_ARCH=$(cat /etc/os-release |grep '^ID='|cut -d'=' -f2|sed 's/"//g'|tr '[:upper:]' '[:lower:]')
 BUILD_FLAGS="-ldflags \"-X github.com/DTFN/dtfn/version.GitCommit=\`git rev-parse --short HEAD\`\""
 BUILD_TAGS=dtfn
-ETH_ACCOUNT=ethAccount
 
 function printHelp () {
     echo "Usage: ./`basename $0` -t [blsdep|glide|build|install|clean]"
@@ -80,14 +79,6 @@ function do_executeCmddtfn() {
     do_move_file ${ROOT_DIR} ${BUILD_TAGS}
 }
 
-function do_executeCmdAccount() {
-    echo "do execute command for account ..."
-    
-    echo $1
-    cd ${ROOT_DIR} && sh -c "$1"
-    do_move_file ${ROOT_DIR} ${ETH_ACCOUNT}
-}
-
 function do_delete_file() {
     if [ -f "$1" ]; then
         sudo rm -f $1
@@ -102,10 +93,6 @@ function do_clean() {
     do_delete_file ${BUILD_TAGS}
     do_delete_file /usr/bin/${BUILD_TAGS}
     do_delete_file ${GOPATH}/bin/${BUILD_TAGS}
-    
-    do_delete_file ${ETH_ACCOUNT}
-    do_delete_file /usr/bin/${ETH_ACCOUNT}
-    do_delete_file ${GOPATH}/bin/${ETH_ACCOUNT}
 }
 
 function main() {
@@ -119,11 +106,9 @@ function main() {
             ;;
         "build")
             do_executeCmddtfn "CGO_ENABLED=1 go build ${BUILD_FLAGS} -o ./${BUILD_TAGS} ./cmd/dtfn"
-            do_executeCmdAccount "CGO_ENABLED=1 go build ${BUILD_FLAGS} -o ./${ETH_ACCOUNT} ./cmd/ethAccount"
             ;;
         "install")
             do_executeCmddtfn "CGO_ENABLED=1 go install ${BUILD_FLAGS} ./cmd/dtfn}"
-            do_executeCmdAccount "CGO_ENABLED=1 go install ${BUILD_FLAGS} ./cmd/ethAccount}"
             ;;
         "clean")
             do_clean
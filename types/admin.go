@@ -0,0 +1,259 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultApprovedTxLifetime is the number of blocks an approved tx can be delivered in
+// when the approval sets no expiry height
+const DefaultApprovedTxLifetime int64 = 1000
+
+// AdminSet is the M-of-N set administrating the chain.
+// While it has no members the single PPChainAdmin of the version config is the admin.
+// need persist when changed this block
+type AdminSet struct {
+	// the set is changed by DeliverTx and EndBlock and read by CheckTx, the queries and the http server
+	mtx sync.RWMutex
+
+	Members   []common.Address `json:"members"`
+	Threshold int              `json:"threshold"`
+
+	Proposals      map[uint64]*AdminProposal `json:"proposals"`
+	NextProposalID uint64                    `json:"next_proposal_id"`
+
+	// ApprovedTxs are the txs of PPChainAdmin approved by the set, tx hash to the last height it can be delivered at
+	ApprovedTxs map[common.Hash]int64 `json:"approved_txs"`
+
+	ChangedFlagThisBlock bool `json:"-"`
+}
+
+// AdminProposal is a system tx waiting for the approval of the admin set
+type AdminProposal struct {
+	ID        uint64           `json:"id"`
+	Proposer  common.Address   `json:"proposer"`
+	Method    string           `json:"method"`
+	Params    json.RawMessage  `json:"params"`
+	Approvals []common.Address `json:"approvals"`
+	Height    int64            `json:"height"`
+}
+
+func NewAdminSet() *AdminSet {
+	adminSet := &AdminSet{}
+	adminSet.InitStruct()
+	return adminSet
+}
+
+// InitStruct makes the maps of an unmarshalled admin set usable
+func (adminSet *AdminSet) InitStruct() {
+	if adminSet.Proposals == nil {
+		adminSet.Proposals = make(map[uint64]*AdminProposal)
+	}
+	if adminSet.ApprovedTxs == nil {
+		adminSet.ApprovedTxs = make(map[common.Hash]int64)
+	}
+}
+
+// Enabled reports whether the chain is administrated by the M-of-N set
+func (adminSet *AdminSet) Enabled() bool {
+	adminSet.mtx.RLock()
+	defer adminSet.mtx.RUnlock()
+
+	return len(adminSet.Members) > 0
+}
+
+func (adminSet *AdminSet) IsMember(account common.Address) bool {
+	adminSet.mtx.RLock()
+	defer adminSet.mtx.RUnlock()
+
+	for _, member := range adminSet.Members {
+		if member == account {
+			return true
+		}
+	}
+	return false
+}
+
+// Copy returns a deep copy of the admin set
+func (adminSet *AdminSet) Copy() *AdminSet {
+	adminSet.mtx.RLock()
+	defer adminSet.mtx.RUnlock()
+
+	setCopy := &AdminSet{
+		Members:              append([]common.Address(nil), adminSet.Members...),
+		Threshold:            adminSet.Threshold,
+		Proposals:            make(map[uint64]*AdminProposal, len(adminSet.Proposals)),
+		NextProposalID:       adminSet.NextProposalID,
+		ApprovedTxs:          make(map[common.Hash]int64, len(adminSet.ApprovedTxs)),
+		ChangedFlagThisBlock: adminSet.ChangedFlagThisBlock,
+	}
+	for id, proposal := range adminSet.Proposals {
+		proposalCopy := *proposal
+		proposalCopy.Params = append(json.RawMessage(nil), proposal.Params...)
+		proposalCopy.Approvals = append([]common.Address(nil), proposal.Approvals...)
+		setCopy.Proposals[id] = &proposalCopy
+	}
+	for hash, expiryHeight := range adminSet.ApprovedTxs {
+		setCopy.ApprovedTxs[hash] = expiryHeight
+	}
+	return setCopy
+}
+
+// ValidateAdminSet checks members and threshold of a new admin set
+func ValidateAdminSet(members []common.Address, threshold int) error {
+	if len(members) == 0 {
+		return errors.New("empty admin set")
+	}
+	seen := make(map[common.Address]bool, len(members))
+	for _, member := range members {
+		if member == (common.Address{}) {
+			return errors.New("empty admin member")
+		}
+		if seen[member] {
+			return fmt.Errorf("duplicated admin member %X", member)
+		}
+		seen[member] = true
+	}
+	if threshold < 1 || threshold > len(members) {
+		return fmt.Errorf("threshold %v out of range [1, %v]", threshold, len(members))
+	}
+	return nil
+}
+
+// SetMembers replaces the admin set, pending proposals were approved by the old set and are dropped
+func (adminSet *AdminSet) SetMembers(members []common.Address, threshold int) {
+	adminSet.mtx.Lock()
+	defer adminSet.mtx.Unlock()
+
+	adminSet.Members = append([]common.Address(nil), members...)
+	adminSet.Threshold = threshold
+	adminSet.Proposals = make(map[uint64]*AdminProposal)
+	adminSet.ChangedFlagThisBlock = true
+}
+
+// AddProposal creates a proposal approved by its proposer
+func (adminSet *AdminSet) AddProposal(proposer common.Address, method string, params json.RawMessage, height int64) *AdminProposal {
+	adminSet.mtx.Lock()
+	defer adminSet.mtx.Unlock()
+
+	adminSet.NextProposalID++
+	proposal := &AdminProposal{
+		ID:        adminSet.NextProposalID,
+		Proposer:  proposer,
+		Method:    method,
+		Params:    params,
+		Approvals: []common.Address{proposer},
+		Height:    height,
+	}
+	adminSet.Proposals[proposal.ID] = proposal
+	adminSet.ChangedFlagThisBlock = true
+	return proposal
+}
+
+// CheckApprove returns an error if member can not approve the proposal
+func (adminSet *AdminSet) CheckApprove(id uint64, member common.Address) error {
+	adminSet.mtx.RLock()
+	defer adminSet.mtx.RUnlock()
+
+	return adminSet.checkApprove(id, member)
+}
+
+func (adminSet *AdminSet) checkApprove(id uint64, member common.Address) error {
+	proposal, ok := adminSet.Proposals[id]
+	if !ok {
+		return fmt.Errorf("proposal %v not found", id)
+	}
+	if proposal.IsApprovedBy(member) {
+		return fmt.Errorf("proposal %v already approved by %X", id, member)
+	}
+	return nil
+}
+
+func (adminSet *AdminSet) Approve(id uint64, member common.Address) (*AdminProposal, error) {
+	adminSet.mtx.Lock()
+	defer adminSet.mtx.Unlock()
+
+	if err := adminSet.checkApprove(id, member); err != nil {
+		return nil, err
+	}
+	proposal := adminSet.Proposals[id]
+	proposal.Approvals = append(proposal.Approvals, member)
+	adminSet.ChangedFlagThisBlock = true
+	return proposal, nil
+}
+
+// Passed reports whether the proposal has enough approvals to be executed
+func (adminSet *AdminSet) Passed(proposal *AdminProposal) bool {
+	adminSet.mtx.RLock()
+	defer adminSet.mtx.RUnlock()
+
+	return len(proposal.Approvals) >= adminSet.Threshold
+}
+
+func (adminSet *AdminSet) RemoveProposal(id uint64) {
+	adminSet.mtx.Lock()
+	defer adminSet.mtx.Unlock()
+
+	delete(adminSet.Proposals, id)
+	adminSet.ChangedFlagThisBlock = true
+}
+
+// ApproveTx approves the tx up to and including expiryHeight
+func (adminSet *AdminSet) ApproveTx(hash common.Hash, expiryHeight int64) {
+	adminSet.mtx.Lock()
+	defer adminSet.mtx.Unlock()
+
+	adminSet.ApprovedTxs[hash] = expiryHeight
+	adminSet.ChangedFlagThisBlock = true
+}
+
+// IsTxApproved reports whether the tx is approved and not expired at height
+func (adminSet *AdminSet) IsTxApproved(hash common.Hash, height int64) bool {
+	adminSet.mtx.RLock()
+	defer adminSet.mtx.RUnlock()
+
+	expiryHeight, ok := adminSet.ApprovedTxs[hash]
+	return ok && height <= expiryHeight
+}
+
+// PruneApprovedTxs removes the approvals expired after height and returns their number
+func (adminSet *AdminSet) PruneApprovedTxs(height int64) int {
+	adminSet.mtx.Lock()
+	defer adminSet.mtx.Unlock()
+
+	count := 0
+	for hash, expiryHeight := range adminSet.ApprovedTxs {
+		if expiryHeight <= height {
+			delete(adminSet.ApprovedTxs, hash)
+			count++
+		}
+	}
+	if count > 0 {
+		adminSet.ChangedFlagThisBlock = true
+	}
+	return count
+}
+
+// ConsumeApprovedTx removes an approved tx once it is delivered
+func (adminSet *AdminSet) ConsumeApprovedTx(hash common.Hash) {
+	adminSet.mtx.Lock()
+	defer adminSet.mtx.Unlock()
+
+	if _, ok := adminSet.ApprovedTxs[hash]; ok {
+		delete(adminSet.ApprovedTxs, hash)
+		adminSet.ChangedFlagThisBlock = true
+	}
+}
+
+func (proposal *AdminProposal) IsApprovedBy(member common.Address) bool {
+	for _, approval := range proposal.Approvals {
+		if approval == member {
+			return true
+		}
+	}
+	return false
+}
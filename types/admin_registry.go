@@ -0,0 +1,120 @@
+package types
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// privileged roles of the admin registry
+const (
+	AdminRolePPChainAdmin = "ppchain_admin"
+	AdminRoleBigguy       = "bigguy"
+)
+
+// AdminRegistry holds the privileged accounts rotated on chain.
+// A role without account keeps the address of the version config.
+// need persist when changed this block
+type AdminRegistry struct {
+	// the registry is changed by DeliverTx and BeginBlock and read by the queries and the http server
+	mtx sync.RWMutex
+
+	PPChainAdmin *common.Address `json:"ppchain_admin,omitempty"`
+	Bigguy       *common.Address `json:"bigguy,omitempty"`
+
+	// Pending rotations, in the order they were submitted
+	Pending []*AdminRotation `json:"pending"`
+	// History of the applied rotations, oldest first
+	History []*AdminRotation `json:"history"`
+
+	ChangedFlagThisBlock bool `json:"-"`
+}
+
+type AdminRotation struct {
+	Role            string         `json:"role"`
+	Account         common.Address `json:"account"`
+	EffectiveHeight int64          `json:"effective_height"`
+	SubmittedHeight int64          `json:"submitted_height"`
+	SubmittedBy     common.Address `json:"submitted_by"`
+}
+
+func NewAdminRegistry() *AdminRegistry {
+	return &AdminRegistry{}
+}
+
+func ValidateAdminRole(role string) error {
+	switch role {
+	case AdminRolePPChainAdmin, AdminRoleBigguy:
+		return nil
+	}
+	return fmt.Errorf("unknown admin role %q", role)
+}
+
+// Schedule adds a rotation taking effect at rotation.EffectiveHeight
+func (registry *AdminRegistry) Schedule(rotation *AdminRotation) {
+	registry.mtx.Lock()
+	defer registry.mtx.Unlock()
+
+	registry.Pending = append(registry.Pending, rotation)
+	registry.ChangedFlagThisBlock = true
+}
+
+// ApplyDue applies the pending rotations effective at height and returns them
+func (registry *AdminRegistry) ApplyDue(height int64) []*AdminRotation {
+	registry.mtx.Lock()
+	defer registry.mtx.Unlock()
+
+	var applied []*AdminRotation
+	pending := registry.Pending[:0]
+	for _, rotation := range registry.Pending {
+		if rotation.EffectiveHeight > height {
+			pending = append(pending, rotation)
+			continue
+		}
+		account := rotation.Account
+		switch rotation.Role {
+		case AdminRolePPChainAdmin:
+			registry.PPChainAdmin = &account
+		case AdminRoleBigguy:
+			registry.Bigguy = &account
+		}
+		applied = append(applied, rotation)
+	}
+	if len(applied) != 0 {
+		registry.Pending = pending
+		registry.History = append(registry.History, applied...)
+		registry.ChangedFlagThisBlock = true
+	}
+	return applied
+}
+
+// Copy returns a deep copy of the registry
+func (registry *AdminRegistry) Copy() *AdminRegistry {
+	registry.mtx.RLock()
+	defer registry.mtx.RUnlock()
+
+	registryCopy := &AdminRegistry{
+		Pending:              copyAdminRotations(registry.Pending),
+		History:              copyAdminRotations(registry.History),
+		ChangedFlagThisBlock: registry.ChangedFlagThisBlock,
+	}
+	if registry.PPChainAdmin != nil {
+		ppChainAdmin := *registry.PPChainAdmin
+		registryCopy.PPChainAdmin = &ppChainAdmin
+	}
+	if registry.Bigguy != nil {
+		bigguy := *registry.Bigguy
+		registryCopy.Bigguy = &bigguy
+	}
+	return registryCopy
+}
+
+func copyAdminRotations(rotations []*AdminRotation) []*AdminRotation {
+	rotationsCopy := make([]*AdminRotation, 0, len(rotations))
+	for _, rotation := range rotations {
+		rotationCopy := *rotation
+		rotationsCopy = append(rotationsCopy, &rotationCopy)
+	}
+	return rotationsCopy
+}
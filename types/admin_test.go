@@ -0,0 +1,50 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminSetCopy(t *testing.T) {
+	members := []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02")}
+	txHash := common.HexToHash("0x03")
+	adminSet := NewAdminSet()
+	adminSet.SetMembers(members, 2)
+	proposal := adminSet.AddProposal(members[0], SystemTxSetDeployRestricted, json.RawMessage(`{"restricted":true}`), 1)
+	adminSet.ApproveTx(txHash, 10)
+
+	setCopy := adminSet.Copy()
+	_, err := adminSet.Approve(proposal.ID, members[1])
+	assert.NoError(t, err)
+	adminSet.RemoveProposal(proposal.ID)
+	adminSet.ConsumeApprovedTx(txHash)
+	adminSet.SetMembers(members[:1], 1)
+
+	assert.Equal(t, members, setCopy.Members)
+	assert.Equal(t, 2, setCopy.Threshold)
+	assert.Equal(t, []common.Address{members[0]}, setCopy.Proposals[proposal.ID].Approvals)
+	assert.True(t, setCopy.IsTxApproved(txHash, 10))
+	assert.False(t, setCopy.Passed(setCopy.Proposals[proposal.ID]))
+}
+
+func TestAdminRegistryCopy(t *testing.T) {
+	admin := common.HexToAddress("0x01")
+	registry := NewAdminRegistry()
+	registry.Schedule(&AdminRotation{Role: AdminRolePPChainAdmin, Account: admin, EffectiveHeight: 5})
+	registry.Schedule(&AdminRotation{Role: AdminRoleBigguy, Account: admin, EffectiveHeight: 10})
+
+	registryCopy := registry.Copy()
+	assert.Len(t, registry.ApplyDue(5), 1)
+	assert.Nil(t, registryCopy.PPChainAdmin)
+	assert.Len(t, registryCopy.Pending, 2)
+	assert.Empty(t, registryCopy.History)
+	assert.Equal(t, AdminRolePPChainAdmin, registryCopy.Pending[0].Role)
+
+	registryCopy = registry.Copy()
+	assert.Equal(t, &admin, registryCopy.PPChainAdmin)
+	assert.Len(t, registryCopy.Pending, 1)
+	assert.Len(t, registryCopy.History, 1)
+}
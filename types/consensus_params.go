@@ -0,0 +1,14 @@
+package types
+
+// ConsensusParamsData holds the consensus parameters shared by tendermint and the eth header.
+// need persist when changed this block
+type ConsensusParamsData struct {
+	// BlockGasLimit drives both tendermint Block.MaxGas and the eth header GasLimit.
+	// 0 means not set, the eth header then falls back to core.CalcGasLimit.
+	BlockGasLimit uint64 `json:"block_gas_limit"`
+
+	// BlockMaxBytes is sent along with MaxGas, tendermint updates both at once.
+	BlockMaxBytes int64 `json:"block_max_bytes"`
+
+	ChangedFlagThisBlock bool `json:"-"`
+}
@@ -1,17 +1,37 @@
 package types
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"github.com/ethereum/go-ethereum/common"
 	cmn "github.com/tendermint/tendermint/libs/common"
+	tmTypes "github.com/tendermint/tendermint/types"
 	"io/ioutil"
 	"math/big"
+	"strings"
 )
 
+// InitialEthAccountVersion is the version of initial_eth_account.json keyed by tm address
+const InitialEthAccountVersion = 2
+
+// EthAccounts is the content of initial_eth_account.json.
+// Version 2 keys Accounts by tm address (or hex pubkey). The legacy format has no version
+// and pairs EthAccounts and EthBeneficiarys with the genesis validators by index.
 type EthAccounts struct {
-	EthAccounts     []string   `json:"ethAccounts"`
-	EthBalances     []*big.Int `json:"ethBalances"`
-	EthBeneficiarys []string   `json:"ethBeneficiarys"`
+	Version  int                           `json:"version,omitempty"`
+	Accounts map[string]*InitialEthAccount `json:"accounts,omitempty"`
+
+	EthAccounts     []string   `json:"ethAccounts,omitempty"`
+	EthBalances     []*big.Int `json:"ethBalances,omitempty"`
+	EthBeneficiarys []string   `json:"ethBeneficiarys,omitempty"`
+}
+
+// InitialEthAccount binds a genesis validator to its eth accounts and bls key
+type InitialEthAccount struct {
+	Signer       common.Address `json:"signer"`
+	Beneficiary  common.Address `json:"beneficiary"`
+	BlsKeyString string         `json:"bls_key_string,omitempty"`
 }
 
 // GetInitialEthAccountFromFile reads JSON data from a file and unmarshalls it into a initial eth accounts.
@@ -34,6 +54,87 @@ func EthAccountsFromJSON(jsonBlob []byte) (*EthAccounts, error) {
 	if err != nil {
 		return nil, err
 	}
+	if ethAccounts.Version > InitialEthAccountVersion {
+		return nil, fmt.Errorf("unsupported initial eth account version %v", ethAccounts.Version)
+	}
+	// tm addresses are upper case hex, accept any case in the file
+	accounts := make(map[string]*InitialEthAccount, len(ethAccounts.Accounts))
+	for key, account := range ethAccounts.Accounts {
+		upperKey := strings.ToUpper(key)
+		if _, found := accounts[upperKey]; found {
+			return nil, fmt.Errorf("initial eth account %v is duplicated", key)
+		}
+		accounts[upperKey] = account
+	}
+	ethAccounts.Accounts = accounts
 
 	return &ethAccounts, err
 }
+
+// lookup returns the entry of a genesis validator, by tm address first and then by hex pubkey
+func (ethAccounts *EthAccounts) lookup(index int, validator tmTypes.GenesisValidator) (*InitialEthAccount, bool) {
+	if ethAccounts.Version < InitialEthAccountVersion {
+		if index >= len(ethAccounts.EthAccounts) || index >= len(ethAccounts.EthBeneficiarys) {
+			return nil, false
+		}
+		return &InitialEthAccount{
+			Signer:      common.HexToAddress(ethAccounts.EthAccounts[index]),
+			Beneficiary: common.HexToAddress(ethAccounts.EthBeneficiarys[index]),
+		}, true
+	}
+	if account, found := ethAccounts.Accounts[validator.PubKey.Address().String()]; found {
+		return account, true
+	}
+	if account, found := ethAccounts.Accounts[strings.ToUpper(hex.EncodeToString(validator.PubKey.Bytes()))]; found {
+		return account, true
+	}
+	return nil, false
+}
+
+// InitialAccountMap pairs every genesis validator with its entry and checks that each signer
+// holds at least threshold in the genesis alloc. All the problems are reported in one error.
+func (ethAccounts *EthAccounts) InitialAccountMap(validators []tmTypes.GenesisValidator,
+	balanceOf func(common.Address) *big.Int, threshold *big.Int) (*AccountMap, error) {
+	amlist := &AccountMap{
+		MapList: make(map[string]*AccountMapItem),
+	}
+	var problems []string
+	for i, validator := range validators {
+		tmAddress := validator.PubKey.Address().String()
+		account, found := ethAccounts.lookup(i, validator)
+		if !found {
+			problems = append(problems, fmt.Sprintf("genesis validator %v %v has no initial eth account", validator.Name, tmAddress))
+			continue
+		}
+		blsKeyJsonStr, _ := json.Marshal(validator.BlsPubKey)
+		blsKeyString := string(blsKeyJsonStr)
+		if account.BlsKeyString != "" {
+			// compare the decoded keys, the file may be formatted differently
+			var blsKey tmTypes.BLSPubKey
+			if err := json.Unmarshal([]byte(account.BlsKeyString), &blsKey); err != nil {
+				problems = append(problems, fmt.Sprintf("genesis validator %v %v: invalid bls key %v: %v",
+					validator.Name, tmAddress, account.BlsKeyString, err))
+				continue
+			}
+			if explicitJsonStr, _ := json.Marshal(blsKey); string(explicitJsonStr) != blsKeyString {
+				problems = append(problems, fmt.Sprintf("genesis validator %v %v: bls key %v differs from the genesis one %v",
+					validator.Name, tmAddress, account.BlsKeyString, blsKeyString))
+				continue
+			}
+		}
+		if balance := balanceOf(account.Signer); balance == nil || balance.Cmp(threshold) < 0 {
+			problems = append(problems, fmt.Sprintf("genesis validator %v %v: signer %v balance %v is below the PosTable threshold %v",
+				validator.Name, tmAddress, account.Signer.Hex(), balance, threshold))
+			continue
+		}
+		amlist.MapList[tmAddress] = &AccountMapItem{
+			account.Signer,
+			account.Beneficiary,
+			blsKeyString,
+		}
+	}
+	if len(problems) != 0 {
+		return nil, fmt.Errorf("invalid initial eth account file:\n  %v", strings.Join(problems, "\n  "))
+	}
+	return amlist, nil
+}
@@ -0,0 +1,132 @@
+package types
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	tmTypes "github.com/tendermint/tendermint/types"
+)
+
+func testGenesisValidators(n int) []tmTypes.GenesisValidator {
+	validators := make([]tmTypes.GenesisValidator, n)
+	for i := range validators {
+		pubKey := ed25519.GenPrivKey().PubKey()
+		validators[i] = tmTypes.GenesisValidator{
+			PubKey:    pubKey,
+			BlsPubKey: tmTypes.BLSPubKey{Type: "Secp256k1", Address: pubKey.Address().String()},
+			Power:     1,
+			Name:      fmt.Sprintf("node%d", i),
+		}
+	}
+	return validators
+}
+
+func testBalanceOf(balances map[common.Address]int64) func(common.Address) *big.Int {
+	return func(address common.Address) *big.Int {
+		if balance, ok := balances[address]; ok {
+			return big.NewInt(balance)
+		}
+		return nil
+	}
+}
+
+func TestEthAccountsLegacy(t *testing.T) {
+	validators := testGenesisValidators(2)
+	signers := []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02")}
+	beneficiaries := []common.Address{common.HexToAddress("0x11"), common.HexToAddress("0x12")}
+	jsonBlob := fmt.Sprintf(`{"ethAccounts":["%v","%v"],"ethBeneficiarys":["%v","%v"]}`,
+		signers[0].Hex(), signers[1].Hex(), beneficiaries[0].Hex(), beneficiaries[1].Hex())
+
+	ethAccounts, err := EthAccountsFromJSON([]byte(jsonBlob))
+	require.NoError(t, err)
+	assert.Equal(t, 0, ethAccounts.Version)
+
+	// the entries are paired with the genesis validators by index
+	accountMap, err := ethAccounts.InitialAccountMap(validators,
+		testBalanceOf(map[common.Address]int64{signers[0]: 10, signers[1]: 10}), big.NewInt(10))
+	require.NoError(t, err)
+	require.Len(t, accountMap.MapList, 2)
+	for i, validator := range validators {
+		item := accountMap.MapList[validator.PubKey.Address().String()]
+		require.NotNil(t, item, validator.Name)
+		assert.Equal(t, signers[i], item.Signer)
+		assert.Equal(t, beneficiaries[i], item.Beneficiary)
+		blsKeyJSON, _ := json.Marshal(validator.BlsPubKey)
+		assert.Equal(t, string(blsKeyJSON), item.BlsKeyString)
+	}
+
+	// a legacy file shorter than the genesis leaves a validator without account
+	_, err = ethAccounts.InitialAccountMap(testGenesisValidators(3),
+		testBalanceOf(map[common.Address]int64{signers[0]: 10, signers[1]: 10}), big.NewInt(10))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "node2")
+	assert.Contains(t, err.Error(), "has no initial eth account")
+}
+
+func TestEthAccountsByTmAddress(t *testing.T) {
+	validators := testGenesisValidators(2)
+	signers := []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02")}
+	blsKeyJSON, _ := json.Marshal(validators[0].BlsPubKey)
+	// the first entry is keyed by lower case tm address, the second one by hex pubkey
+	jsonBlob := fmt.Sprintf(`{"version":2,"accounts":{
+		"%v":{"signer":"%v","beneficiary":"%v","bls_key_string":%q},
+		"%v":{"signer":"%v","beneficiary":"%v"}}}`,
+		strings.ToLower(validators[0].PubKey.Address().String()), signers[0].Hex(), signers[0].Hex(), blsKeyJSON,
+		hex.EncodeToString(validators[1].PubKey.Bytes()), signers[1].Hex(), signers[1].Hex())
+
+	ethAccounts, err := EthAccountsFromJSON([]byte(jsonBlob))
+	require.NoError(t, err)
+	assert.Equal(t, InitialEthAccountVersion, ethAccounts.Version)
+
+	// the order of the genesis does not matter
+	reversed := []tmTypes.GenesisValidator{validators[1], validators[0]}
+	accountMap, err := ethAccounts.InitialAccountMap(reversed,
+		testBalanceOf(map[common.Address]int64{signers[0]: 10, signers[1]: 10}), big.NewInt(10))
+	require.NoError(t, err)
+	for i, validator := range validators {
+		item := accountMap.MapList[validator.PubKey.Address().String()]
+		require.NotNil(t, item, validator.Name)
+		assert.Equal(t, signers[i], item.Signer)
+	}
+
+	_, err = EthAccountsFromJSON([]byte(`{"version":3}`))
+	assert.Error(t, err)
+	_, err = EthAccountsFromJSON([]byte(`{"version":2,"accounts":{"ab":{},"AB":{}}}`))
+	assert.Error(t, err)
+}
+
+func TestEthAccountsReport(t *testing.T) {
+	validators := testGenesisValidators(4)
+	rich := common.HexToAddress("0x01")
+	poor := common.HexToAddress("0x02")
+	otherBlsKey, _ := json.Marshal(tmTypes.BLSPubKey{Type: "Secp256k1", Address: "other"})
+	ethAccounts := &EthAccounts{
+		Version: InitialEthAccountVersion,
+		Accounts: map[string]*InitialEthAccount{
+			validators[0].PubKey.Address().String(): {Signer: rich, Beneficiary: rich},
+			validators[1].PubKey.Address().String(): {Signer: poor, Beneficiary: poor},
+			validators[2].PubKey.Address().String(): {Signer: rich, Beneficiary: rich, BlsKeyString: string(otherBlsKey)},
+		},
+	}
+
+	// every problem is reported at once
+	_, err := ethAccounts.InitialAccountMap(validators,
+		testBalanceOf(map[common.Address]int64{rich: 10, poor: 9}), big.NewInt(10))
+	require.Error(t, err)
+	problems := strings.Split(err.Error(), "\n")[1:]
+	require.Len(t, problems, 3, err.Error())
+	assert.Contains(t, problems[0], "node1")
+	assert.Contains(t, problems[0], "below the PosTable threshold")
+	assert.Contains(t, problems[1], "node2")
+	assert.Contains(t, problems[1], "differs from the genesis one")
+	assert.Contains(t, problems[2], "node3")
+	assert.Contains(t, problems[2], "has no initial eth account")
+}
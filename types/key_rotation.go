@@ -0,0 +1,148 @@
+package types
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txfilter"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+)
+
+// ConsensusKeyRotation replaces the tendermint key of a bonded signer at the next epoch boundary.
+// The old tm address stays an alias of the signer for one more epoch, tendermint applies
+// validator updates two heights later and the old key still votes in between.
+type ConsensusKeyRotation struct {
+	Signer          common.Address   `json:"signer"`
+	OldPubKey       abciTypes.PubKey `json:"old_pub_key"`
+	OldTmAddress    string           `json:"old_tm_address"`
+	NewPubKey       abciTypes.PubKey `json:"new_pub_key"`
+	NewTmAddress    string           `json:"new_tm_address"`
+	SubmittedHeight int64            `json:"submitted_height"`
+	EffectiveHeight int64            `json:"effective_height"` // epoch boundary of the switch, 0 while pending
+}
+
+// need persist when changed this block
+type ConsensusKeyRotations struct {
+	// the rotations are changed by DeliverTx and EndBlock and read by the queries
+	mtx sync.RWMutex
+
+	// Pending rotations by signer, the new key is already in NextEpochValData.PosTable
+	Pending map[common.Address]*ConsensusKeyRotation `json:"pending"`
+	// Retiring rotations switched at the last epoch boundary, their old address is still an alias in CurrEpochValData.PosTable
+	Retiring []*ConsensusKeyRotation `json:"retiring"`
+
+	ChangedFlagThisBlock bool `json:"-"`
+}
+
+func NewConsensusKeyRotations() *ConsensusKeyRotations {
+	rotations := &ConsensusKeyRotations{}
+	rotations.InitStruct()
+	return rotations
+}
+
+func (rotations *ConsensusKeyRotations) InitStruct() {
+	if rotations.Pending == nil {
+		rotations.Pending = make(map[common.Address]*ConsensusKeyRotation)
+	}
+}
+
+// Schedule binds the new key to the signer in next and keeps the old address as an alias.
+// It returns a copy of posItem moved to the new key, the caller replaces the PosItem of next with it:
+// CurrEpochValData shares the PosItem until the epoch boundary and keeps the old key meanwhile.
+// A second rotation in the same epoch replaces the first one, its key was never active.
+func (rotations *ConsensusKeyRotations) Schedule(next *txfilter.PosTable, signer common.Address, posItem *txfilter.PosItem,
+	newPubKey abciTypes.PubKey, newTmAddress string, height int64) *txfilter.PosItem {
+	rotations.mtx.Lock()
+	defer rotations.mtx.Unlock()
+
+	rotation, ok := rotations.Pending[signer]
+	if ok {
+		delete(next.TmAddressToSignerMap, rotation.NewTmAddress)
+	} else {
+		rotation = &ConsensusKeyRotation{
+			Signer:       signer,
+			OldPubKey:    posItem.PubKey,
+			OldTmAddress: posItem.TmAddress,
+		}
+		rotations.Pending[signer] = rotation
+	}
+	rotation.NewPubKey = newPubKey
+	rotation.NewTmAddress = newTmAddress
+	rotation.SubmittedHeight = height
+
+	updated := *posItem
+	updated.PubKey = newPubKey
+	updated.TmAddress = newTmAddress
+	next.TmAddressToSignerMap[newTmAddress] = signer
+	next.ChangedFlagThisBlock = true
+	rotations.ChangedFlagThisBlock = true
+	return &updated
+}
+
+// SwitchEpoch is called at the epoch boundary once CurrEpochValData copied next.
+// The pending rotations become active and their aliases are dropped from next.
+func (rotations *ConsensusKeyRotations) SwitchEpoch(next *txfilter.PosTable, height int64) {
+	rotations.mtx.Lock()
+	defer rotations.mtx.Unlock()
+
+	if len(rotations.Pending) == 0 && len(rotations.Retiring) == 0 {
+		return
+	}
+	retiring := make([]*ConsensusKeyRotation, 0, len(rotations.Pending))
+	for _, rotation := range rotations.Pending {
+		retiring = append(retiring, rotation)
+	}
+	// map order is random, the persisted list must be the same on every node
+	sort.Slice(retiring, func(i, j int) bool {
+		return bytes.Compare(retiring[i].Signer.Bytes(), retiring[j].Signer.Bytes()) < 0
+	})
+	for _, rotation := range retiring {
+		rotation.EffectiveHeight = height
+		if next.TmAddressToSignerMap[rotation.OldTmAddress] == rotation.Signer {
+			delete(next.TmAddressToSignerMap, rotation.OldTmAddress)
+		}
+	}
+	rotations.Retiring = retiring
+	rotations.Pending = make(map[common.Address]*ConsensusKeyRotation)
+	rotations.ChangedFlagThisBlock = true
+}
+
+// RestoreAliases puts back the old addresses after the PosTables were read from the state
+func (rotations *ConsensusKeyRotations) RestoreAliases(curr, next *txfilter.PosTable) {
+	rotations.mtx.RLock()
+	defer rotations.mtx.RUnlock()
+
+	for _, rotation := range rotations.Pending {
+		if _, ok := next.TmAddressToSignerMap[rotation.OldTmAddress]; !ok {
+			next.TmAddressToSignerMap[rotation.OldTmAddress] = rotation.Signer
+		}
+	}
+	for _, rotation := range rotations.Retiring {
+		if _, ok := curr.TmAddressToSignerMap[rotation.OldTmAddress]; !ok {
+			curr.TmAddressToSignerMap[rotation.OldTmAddress] = rotation.Signer
+		}
+	}
+}
+
+// Copy returns a deep copy of the rotations
+func (rotations *ConsensusKeyRotations) Copy() *ConsensusKeyRotations {
+	rotations.mtx.RLock()
+	defer rotations.mtx.RUnlock()
+
+	rotationsCopy := &ConsensusKeyRotations{
+		Pending:              make(map[common.Address]*ConsensusKeyRotation, len(rotations.Pending)),
+		Retiring:             make([]*ConsensusKeyRotation, 0, len(rotations.Retiring)),
+		ChangedFlagThisBlock: rotations.ChangedFlagThisBlock,
+	}
+	for signer, rotation := range rotations.Pending {
+		rotationCopy := *rotation
+		rotationsCopy.Pending[signer] = &rotationCopy
+	}
+	for _, rotation := range rotations.Retiring {
+		rotationCopy := *rotation
+		rotationsCopy.Retiring = append(rotationsCopy.Retiring, &rotationCopy)
+	}
+	return rotationsCopy
+}
@@ -0,0 +1,27 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PosTable actions taken on a slashed signer
+const (
+	SlashActionRemoved         = "removed"          // bonded signer kicked out of the PosTable
+	SlashActionAlreadyUnbonded = "already_unbonded" // signer was already unbonding
+)
+
+// SlashRecord is the journal entry of one processed evidence
+type SlashRecord struct {
+	Height         int64          `json:"height"`
+	Index          int            `json:"index"` // position of the evidence in the block
+	EvidenceType   string         `json:"evidence_type"`
+	EvidenceHeight int64          `json:"evidence_height"`
+	TmAddress      string         `json:"tm_address"`
+	Signer         common.Address `json:"signer"`
+	Amount         *big.Int       `json:"amount"`
+	// Destination received the amount, nil means it was burned
+	Destination    *common.Address `json:"destination"`
+	PosTableAction string          `json:"pos_table_action"`
+}
@@ -10,6 +10,7 @@ import (
 	tmlibs "github.com/tendermint/tendermint/libs/common"
 	"math/big"
 	"fmt"
+	"sync"
 	"github.com/tendermint/tendermint/crypto"
 	"github.com/DTFN/dtfn/version"
 )
@@ -51,10 +52,29 @@ type Strategy struct {
 
 	AuthTable *txfilter.AuthTable
 
+	// need persist when changed this block
+	ConsensusParams ConsensusParamsData
+
+	// need persist when changed this block
+	TxPolicy *TxPolicy
+
+	// need persist when changed this block
+	AdminSet *AdminSet
+
+	// need persist when changed this block
+	AdminRegistry *AdminRegistry
+
+	// need persist when changed this block
+	ConsensusKeyRotations *ConsensusKeyRotations
+
 	// add for hard fork
 	HFExpectedData HardForkExpectedData
 
 	signer ethTypes.Signer
+
+	// the validators of CurrentHeightValData and the PosTable of CurrEpochValData are changed
+	// in BeginBlock and EndBlock and read by the rpc
+	mtx sync.RWMutex
 }
 
 type NextEpochValData struct {
@@ -120,6 +140,11 @@ func NewStrategy() *Strategy {
 			PosTable: nil, //later assigned in InitPersistData
 		},
 		HFExpectedData: hfExpectedData,
+		TxPolicy:       NewTxPolicy(),
+		AdminSet:       NewAdminSet(),
+		AdminRegistry:  NewAdminRegistry(),
+
+		ConsensusKeyRotations: NewConsensusKeyRotations(),
 
 		NextEpochValData: NextEpochValData{
 			PosTable: nil, //later assigned in InitPersistData
@@ -132,6 +157,9 @@ func NewStrategy() *Strategy {
 }
 
 func (strategy *Strategy) GetUpdatedValidators(height int64, seed []byte) abciTypes.ResponseEndBlock {
+	strategy.mtx.Lock()
+	defer strategy.mtx.Unlock()
+
 	if height%txfilter.EpochBlocks != 0 {
 		if seed != nil {
 			//seed 存在的时，优先seed
@@ -305,12 +333,12 @@ func (strategy *Strategy) blsValidators(height int64) abciTypes.ResponseEndBlock
 	abiEvents := make([]abciTypes.Event, 0)
 	//get all validators and init tm-auth-table
 	if height == version.HeightArray[2] {
-		txfilter.PPChainAdmin = common.HexToAddress(version.PPChainAdmin)
+		strategy.switchPPChainAdmin(version.PPChainAdmin)
 	} else if height == version.HeightArray[3] {
 		initEvent := abciTypes.Event{Type: "AuthTableInit"}
 		abiEvents = append(abiEvents, initEvent)
 		// Private PPChain Admin account
-		txfilter.PPChainAdmin = common.HexToAddress(version.PPChainPrivateAdmin)
+		strategy.switchPPChainAdmin(version.PPChainPrivateAdmin)
 	}
 	abiEvent := strategy.getAuthTmItems(height)
 	if abiEvent != nil {
@@ -322,6 +350,63 @@ func (strategy *Strategy) blsValidators(height int64) abciTypes.ResponseEndBlock
 	return abciTypes.ResponseEndBlock{ValidatorUpdates: validatorsSlice, BlsKeyString: blsPubkeySlice, AppVersion: strategy.HFExpectedData.BlockVersion}
 }
 
+// SetCurrentHeight sets the height of CurrentHeightValData at BeginBlock
+func (strategy *Strategy) SetCurrentHeight(height int64) {
+	strategy.mtx.Lock()
+	defer strategy.mtx.Unlock()
+
+	strategy.CurrentHeightValData.Height = height
+}
+
+// SetCurrEpochPosTable makes posTable the PosTable of the current epoch
+func (strategy *Strategy) SetCurrEpochPosTable(posTable *txfilter.PosTable) {
+	strategy.mtx.Lock()
+	defer strategy.mtx.Unlock()
+
+	strategy.CurrEpochValData.PosTable = posTable
+}
+
+// CopyCurrentHeightValData returns a copy of the height and the validators of CurrentHeightValData
+func (strategy *Strategy) CopyCurrentHeightValData() CurrentHeightValData {
+	strategy.mtx.RLock()
+	defer strategy.mtx.RUnlock()
+
+	validators := make(map[string]Validator, len(strategy.CurrentHeightValData.Validators))
+	for tmAddress, v := range strategy.CurrentHeightValData.Validators {
+		validators[tmAddress] = v
+	}
+	return CurrentHeightValData{
+		Height:     strategy.CurrentHeightValData.Height,
+		Validators: validators,
+	}
+}
+
+// CopyCurrEpochValData returns a copy of CurrEpochValData, its PosTable is copied too
+func (strategy *Strategy) CopyCurrEpochValData() CurrEpochValData {
+	strategy.mtx.RLock()
+	defer strategy.mtx.RUnlock()
+
+	epoch := strategy.CurrEpochValData
+	if epoch.PosTable != nil {
+		epoch.PosTable = epoch.PosTable.Copy()
+	}
+	if epoch.TotalBalance != nil {
+		epoch.TotalBalance = new(big.Int).Set(epoch.TotalBalance)
+	}
+	if epoch.MinorBonus != nil {
+		epoch.MinorBonus = new(big.Int).Set(epoch.MinorBonus)
+	}
+	return epoch
+}
+
+// switchPPChainAdmin applies the admin of the version config unless the admin registry rotated it on chain
+func (strategy *Strategy) switchPPChainAdmin(admin string) {
+	if strategy.AdminRegistry.PPChainAdmin != nil {
+		return
+	}
+	txfilter.PPChainAdmin = common.HexToAddress(admin)
+}
+
 func (strategy *Strategy) getAuthTmItems(height int64) *abciTypes.Event {
 	if strategy.HFExpectedData.BlockVersion >= 5 && len(strategy.AuthTable.ThisBlockChangedMap) != 0 {
 		abiEvent := &abciTypes.Event{Type: "AuthItem"}
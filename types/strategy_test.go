@@ -1 +1,30 @@
 package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+)
+
+func TestStrategyCopies(t *testing.T) {
+	strategy := NewStrategy()
+	strategy.SetCurrentHeight(7)
+	strategy.CurrentHeightValData.Validators["A"] = Validator{abciTypes.ValidatorUpdate{Power: 1}, common.HexToAddress("0x01")}
+	strategy.CurrEpochValData.TotalBalance = big.NewInt(100)
+	strategy.CurrEpochValData.SelectCount = 4
+
+	heightValData := strategy.CopyCurrentHeightValData()
+	epoch := strategy.CopyCurrEpochValData()
+	strategy.SetCurrentHeight(8)
+	delete(strategy.CurrentHeightValData.Validators, "A")
+	strategy.CurrEpochValData.TotalBalance.SetInt64(200)
+
+	assert.Equal(t, int64(7), heightValData.Height)
+	assert.Len(t, heightValData.Validators, 1)
+	assert.Equal(t, big.NewInt(100), epoch.TotalBalance)
+	assert.Equal(t, 4, epoch.SelectCount)
+	assert.Nil(t, epoch.PosTable)
+}
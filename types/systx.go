@@ -0,0 +1,129 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+)
+
+// SystemTxAddress receives the system txs handled by the ABCI app itself.
+// A system tx is a plain eth tx to this address, its data is a json encoded SystemTx.
+// It is executed by the evm like a transfer, the app applies its effect afterwards.
+var SystemTxAddress = common.HexToAddress("0x0000000000000000000000000000000000001000")
+
+// system tx methods
+const (
+	SystemTxSetBlockGasLimit = "setBlockGasLimit"
+
+	SystemTxSetDeployRestricted = "setDeployRestricted"
+	SystemTxAddDeployer         = "addDeployer"
+	SystemTxRemoveDeployer      = "removeDeployer"
+	SystemTxSetContractCallers  = "setContractCallers"
+	SystemTxSetValueLimit       = "setValueLimit"
+
+	// propose and approve drive the M-of-N admin set, the other methods are
+	// submitted through propose once the set is configured
+	SystemTxPropose     = "propose"
+	SystemTxApprove     = "approve"
+	SystemTxSetAdminSet = "setAdminSet"
+	SystemTxApproveTx   = "approveTx"
+	SystemTxRotateAdmin = "rotateAdmin"
+
+	// validator methods are signed by the signer of a bonded validator
+	SystemTxUpdateBeneficiary  = "updateBeneficiary"
+	SystemTxRotateBlsKey       = "rotateBlsKey"
+	SystemTxRotateConsensusKey = "rotateConsensusKey"
+)
+
+var ErrNotSystemTx = errors.New("not a system tx")
+
+type SystemTx struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type SetBlockGasLimitParams struct {
+	GasLimit uint64 `json:"gasLimit"`
+}
+
+type SetDeployRestrictedParams struct {
+	Restricted bool `json:"restricted"`
+}
+
+// AccountParams is used by addDeployer and removeDeployer
+type AccountParams struct {
+	Account common.Address `json:"account"`
+}
+
+type SetContractCallersParams struct {
+	Contract common.Address   `json:"contract"`
+	Callers  []common.Address `json:"callers"`
+}
+
+// SetValueLimitParams removes the limit of the account if Limit is null
+type SetValueLimitParams struct {
+	Account common.Address `json:"account"`
+	Limit   *big.Int       `json:"limit"`
+}
+
+// IsSystemTx returns true if the tx is sent to SystemTxAddress
+func IsSystemTx(to *common.Address) bool {
+	return to != nil && *to == SystemTxAddress
+}
+
+// DecodeSystemTx unmarshalls the data of a system tx
+func DecodeSystemTx(data []byte) (*SystemTx, error) {
+	systemTx := &SystemTx{}
+	if err := json.Unmarshal(data, systemTx); err != nil {
+		return nil, err
+	}
+	if systemTx.Method == "" {
+		return nil, ErrNotSystemTx
+	}
+	return systemTx, nil
+}
+
+type ProposeParams struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type ApproveParams struct {
+	ID uint64 `json:"id"`
+}
+
+type SetAdminSetParams struct {
+	Members   []common.Address `json:"members"`
+	Threshold int              `json:"threshold"`
+}
+
+// ApproveTxParams approves a signed tx of PPChainAdmin to the mint or auth address.
+// The approval expires after ExpiryHeight, 0 is DefaultApprovedTxLifetime blocks after the approval.
+type ApproveTxParams struct {
+	Hash         common.Hash `json:"hash"`
+	ExpiryHeight int64       `json:"expiryHeight"`
+}
+
+// RotateAdminParams schedules the replacement of a privileged account, see AdminRegistry
+type RotateAdminParams struct {
+	Role            string         `json:"role"`
+	Account         common.Address `json:"account"`
+	EffectiveHeight int64          `json:"effectiveHeight"`
+}
+
+type UpdateBeneficiaryParams struct {
+	Beneficiary common.Address `json:"beneficiary"`
+}
+
+// RotateBlsKeyParams carries the json encoded bls public key, as in the initial account map
+type RotateBlsKeyParams struct {
+	BlsKeyString string `json:"blsKeyString"`
+}
+
+// RotateConsensusKeyParams carries the new tendermint public key, e.g. {"type":"ed25519","data":"<base64>"}
+type RotateConsensusKeyParams struct {
+	PubKey abciTypes.PubKey `json:"pubKey"`
+}
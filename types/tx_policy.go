@@ -0,0 +1,145 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TxPolicy allow-lists contract creation, contract calls and transfer values.
+// Every rule is off until an admin system tx sets it, so a chain without
+// policy behaves as before. Only the top level call of a tx is checked.
+// need persist when changed this block
+type TxPolicy struct {
+	// the policy is changed by DeliverTx and read by CheckTx and the http server
+	mtx sync.RWMutex
+
+	// DeployRestricted limits contract creation to Deployers
+	DeployRestricted bool                    `json:"deploy_restricted"`
+	Deployers        map[common.Address]bool `json:"deployers"`
+
+	// ContractCallers limits the callers of a listed contract, unlisted contracts are open
+	ContractCallers map[common.Address]map[common.Address]bool `json:"contract_callers"`
+
+	// ValueLimits caps the value a listed account may send in one tx
+	ValueLimits map[common.Address]*big.Int `json:"value_limits"`
+
+	ChangedFlagThisBlock bool `json:"-"`
+}
+
+func NewTxPolicy() *TxPolicy {
+	txPolicy := &TxPolicy{}
+	txPolicy.InitStruct()
+	return txPolicy
+}
+
+// InitStruct makes the maps of an unmarshalled policy usable
+func (txPolicy *TxPolicy) InitStruct() {
+	if txPolicy.Deployers == nil {
+		txPolicy.Deployers = make(map[common.Address]bool)
+	}
+	if txPolicy.ContractCallers == nil {
+		txPolicy.ContractCallers = make(map[common.Address]map[common.Address]bool)
+	}
+	if txPolicy.ValueLimits == nil {
+		txPolicy.ValueLimits = make(map[common.Address]*big.Int)
+	}
+}
+
+// Check returns an error if the policy forbids from to send value to `to`, nil `to` is a contract creation.
+func (txPolicy *TxPolicy) Check(from common.Address, to *common.Address, value *big.Int) error {
+	txPolicy.mtx.RLock()
+	defer txPolicy.mtx.RUnlock()
+
+	if to == nil {
+		if txPolicy.DeployRestricted && !txPolicy.Deployers[from] {
+			return fmt.Errorf("%X is not allowed to deploy contracts", from)
+		}
+	} else if callers, ok := txPolicy.ContractCallers[*to]; ok && !callers[from] {
+		return fmt.Errorf("%X is not allowed to call contract %X", from, *to)
+	}
+	if limit, ok := txPolicy.ValueLimits[from]; ok && value.Cmp(limit) > 0 {
+		return fmt.Errorf("value %v of %X exceeds the limit %v", value, from, limit)
+	}
+	return nil
+}
+
+// Copy returns a deep copy of the policy
+func (txPolicy *TxPolicy) Copy() *TxPolicy {
+	txPolicy.mtx.RLock()
+	defer txPolicy.mtx.RUnlock()
+
+	policyCopy := &TxPolicy{
+		DeployRestricted:     txPolicy.DeployRestricted,
+		Deployers:            make(map[common.Address]bool, len(txPolicy.Deployers)),
+		ContractCallers:      make(map[common.Address]map[common.Address]bool, len(txPolicy.ContractCallers)),
+		ValueLimits:          make(map[common.Address]*big.Int, len(txPolicy.ValueLimits)),
+		ChangedFlagThisBlock: txPolicy.ChangedFlagThisBlock,
+	}
+	for account, allowed := range txPolicy.Deployers {
+		policyCopy.Deployers[account] = allowed
+	}
+	for contract, callers := range txPolicy.ContractCallers {
+		callersCopy := make(map[common.Address]bool, len(callers))
+		for caller, allowed := range callers {
+			callersCopy[caller] = allowed
+		}
+		policyCopy.ContractCallers[contract] = callersCopy
+	}
+	for account, limit := range txPolicy.ValueLimits {
+		policyCopy.ValueLimits[account] = new(big.Int).Set(limit)
+	}
+	return policyCopy
+}
+
+func (txPolicy *TxPolicy) SetDeployRestricted(restricted bool) {
+	txPolicy.mtx.Lock()
+	defer txPolicy.mtx.Unlock()
+
+	txPolicy.DeployRestricted = restricted
+	txPolicy.ChangedFlagThisBlock = true
+}
+
+func (txPolicy *TxPolicy) SetDeployer(account common.Address, allowed bool) {
+	txPolicy.mtx.Lock()
+	defer txPolicy.mtx.Unlock()
+
+	if allowed {
+		txPolicy.Deployers[account] = true
+	} else {
+		delete(txPolicy.Deployers, account)
+	}
+	txPolicy.ChangedFlagThisBlock = true
+}
+
+// SetContractCallers replaces the callers of contract, empty callers removes the restriction
+func (txPolicy *TxPolicy) SetContractCallers(contract common.Address, callers []common.Address) {
+	txPolicy.mtx.Lock()
+	defer txPolicy.mtx.Unlock()
+
+	if len(callers) == 0 {
+		delete(txPolicy.ContractCallers, contract)
+	} else {
+		callerMap := make(map[common.Address]bool, len(callers))
+		for _, caller := range callers {
+			callerMap[caller] = true
+		}
+		txPolicy.ContractCallers[contract] = callerMap
+	}
+	txPolicy.ChangedFlagThisBlock = true
+}
+
+// SetValueLimit sets the value limit of account, nil removes it
+func (txPolicy *TxPolicy) SetValueLimit(account common.Address, limit *big.Int) {
+	txPolicy.mtx.Lock()
+	defer txPolicy.mtx.Unlock()
+
+	if limit == nil {
+		delete(txPolicy.ValueLimits, account)
+	} else {
+		txPolicy.ValueLimits[account] = new(big.Int).Set(limit)
+	}
+	txPolicy.ChangedFlagThisBlock = true
+}
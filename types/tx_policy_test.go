@@ -0,0 +1,79 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxPolicyDeploy(t *testing.T) {
+	deployer := common.HexToAddress("0x01")
+	user := common.HexToAddress("0x02")
+	txPolicy := NewTxPolicy()
+	assert.NoError(t, txPolicy.Check(user, nil, big.NewInt(0)))
+
+	txPolicy.SetDeployRestricted(true)
+	txPolicy.SetDeployer(deployer, true)
+	assert.True(t, txPolicy.ChangedFlagThisBlock)
+	assert.NoError(t, txPolicy.Check(deployer, nil, big.NewInt(0)))
+	assert.Error(t, txPolicy.Check(user, nil, big.NewInt(0)))
+
+	txPolicy.SetDeployer(deployer, false)
+	assert.Error(t, txPolicy.Check(deployer, nil, big.NewInt(0)))
+	txPolicy.SetDeployRestricted(false)
+	assert.NoError(t, txPolicy.Check(user, nil, big.NewInt(0)))
+}
+
+func TestTxPolicyCall(t *testing.T) {
+	caller := common.HexToAddress("0x01")
+	user := common.HexToAddress("0x02")
+	contract := common.HexToAddress("0x03")
+	other := common.HexToAddress("0x04")
+	txPolicy := NewTxPolicy()
+
+	txPolicy.SetContractCallers(contract, []common.Address{caller})
+	assert.NoError(t, txPolicy.Check(caller, &contract, big.NewInt(0)))
+	assert.Error(t, txPolicy.Check(user, &contract, big.NewInt(0)))
+	// an unlisted contract is open
+	assert.NoError(t, txPolicy.Check(user, &other, big.NewInt(0)))
+
+	txPolicy.SetContractCallers(contract, nil)
+	assert.NoError(t, txPolicy.Check(user, &contract, big.NewInt(0)))
+}
+
+func TestTxPolicyValue(t *testing.T) {
+	user := common.HexToAddress("0x01")
+	to := common.HexToAddress("0x02")
+	txPolicy := NewTxPolicy()
+
+	limit := big.NewInt(100)
+	txPolicy.SetValueLimit(user, limit)
+	limit.SetInt64(1000) // the policy keeps its own copy
+	assert.NoError(t, txPolicy.Check(user, &to, big.NewInt(100)))
+	assert.Error(t, txPolicy.Check(user, &to, big.NewInt(101)))
+	// the limit applies to contract creation too
+	assert.Error(t, txPolicy.Check(user, nil, big.NewInt(101)))
+
+	txPolicy.SetValueLimit(user, nil)
+	assert.NoError(t, txPolicy.Check(user, &to, big.NewInt(101)))
+}
+
+func TestTxPolicyCopy(t *testing.T) {
+	account := common.HexToAddress("0x01")
+	contract := common.HexToAddress("0x02")
+	txPolicy := NewTxPolicy()
+	txPolicy.SetDeployer(account, true)
+	txPolicy.SetContractCallers(contract, []common.Address{account})
+	txPolicy.SetValueLimit(account, big.NewInt(100))
+
+	policyCopy := txPolicy.Copy()
+	txPolicy.SetDeployer(account, false)
+	txPolicy.SetContractCallers(contract, nil)
+	txPolicy.SetValueLimit(account, big.NewInt(1))
+
+	assert.True(t, policyCopy.Deployers[account])
+	assert.True(t, policyCopy.ContractCallers[contract][account])
+	assert.Equal(t, big.NewInt(100), policyCopy.ValueLimits[account])
+}
@@ -14,12 +14,18 @@ type conf struct {
 }
 
 type VersionData struct {
-	HeightString         string `yaml:"height"`
-	VersionString        string `yaml:"version"`
-	PPCAdmin             string `yaml:"ppcadmin"`
-	BigGuy               string `yaml:"bigguy"`
-	PPChainPrivateAdmin  string `yaml:"ppchainprivateadmin"`
-	EvmErrHardForkHeight int64  `yaml:"evmerrhardforkheight"`
+	HeightString           string `yaml:"height"`
+	VersionString          string `yaml:"version"`
+	PPCAdmin               string `yaml:"ppcadmin"`
+	BigGuy                 string `yaml:"bigguy"`
+	PPChainPrivateAdmin    string `yaml:"ppchainprivateadmin"`
+	EvmErrHardForkHeight   int64  `yaml:"evmerrhardforkheight"`
+	MinGasPriceHeight      int64  `yaml:"mingaspriceheight"`
+	MinGasPrice            string `yaml:"mingasprice"`
+	BaseFeeHeight          int64  `yaml:"basefeeheight"`
+	BlockGasLimit          uint64 `yaml:"blockgaslimit"`
+	BlockGasLimitHeight    int64  `yaml:"blockgaslimitheight"`
+	ReceiptBlockHashHeight int64  `yaml:"receiptblockhashheight"`
 }
 
 func ReadConfig(fileName string) (conf, error) {
@@ -43,6 +49,12 @@ func LoadDevelopConfig(c conf) {
 	PPChainPrivateAdmin = c.Develop.PPChainPrivateAdmin
 	EvmErrHardForkHeight = c.Develop.EvmErrHardForkHeight
 	Bigguy = c.Develop.BigGuy
+	MinGasPriceHeight = c.Develop.MinGasPriceHeight
+	MinGasPriceString = c.Develop.MinGasPrice
+	BaseFeeHeight = c.Develop.BaseFeeHeight
+	BlockGasLimit = c.Develop.BlockGasLimit
+	BlockGasLimitHeight = c.Develop.BlockGasLimitHeight
+	ReceiptBlockHashHeight = c.Develop.ReceiptBlockHashHeight
 }
 
 func LoadStagingConfig(c conf) {
@@ -52,6 +64,12 @@ func LoadStagingConfig(c conf) {
 	PPChainPrivateAdmin = c.Staging.PPChainPrivateAdmin
 	EvmErrHardForkHeight = c.Staging.EvmErrHardForkHeight
 	Bigguy = c.Staging.BigGuy
+	MinGasPriceHeight = c.Staging.MinGasPriceHeight
+	MinGasPriceString = c.Staging.MinGasPrice
+	BaseFeeHeight = c.Staging.BaseFeeHeight
+	BlockGasLimit = c.Staging.BlockGasLimit
+	BlockGasLimitHeight = c.Staging.BlockGasLimitHeight
+	ReceiptBlockHashHeight = c.Staging.ReceiptBlockHashHeight
 }
 
 func LoadProductionConfig(c conf) {
@@ -61,6 +79,12 @@ func LoadProductionConfig(c conf) {
 	PPChainPrivateAdmin = c.Production.PPChainPrivateAdmin
 	EvmErrHardForkHeight = c.Production.EvmErrHardForkHeight
 	Bigguy = c.Production.BigGuy
+	MinGasPriceHeight = c.Production.MinGasPriceHeight
+	MinGasPriceString = c.Production.MinGasPrice
+	BaseFeeHeight = c.Production.BaseFeeHeight
+	BlockGasLimit = c.Production.BlockGasLimit
+	BlockGasLimitHeight = c.Production.BlockGasLimitHeight
+	ReceiptBlockHashHeight = c.Production.ReceiptBlockHashHeight
 }
 
 func LoadDefaultConfig(c conf) {
@@ -71,4 +95,9 @@ func LoadDefaultConfig(c conf) {
 	PPChainPrivateAdmin = "0xb3d49259b486d04505b0b652ade74849c0b703c3"
 	AccountAdmin = "0xb3d49259b486d04505b0b652ade74849c0b703c3"
 	PPChainAdmin = "0xb3d49259b486d04505b0b652ade74849c0b703c3"
-}
\ No newline at end of file
+	MinGasPriceHeight = 0 //disabled
+	MinGasPriceString = "0"
+	BaseFeeHeight = 0 //disabled
+	BlockGasLimit = 0 //use the gas limit of the genesis block
+	BlockGasLimitHeight = 0
+}
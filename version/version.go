@@ -1,6 +1,8 @@
 package version
 
 import (
+	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 )
@@ -38,6 +40,24 @@ var (
 	HeightString string
 
 	VersionString string
+
+	// MinGasPriceHeight is the height from which MinGasPrice is enforced at consensus level, 0 disables it
+	MinGasPriceHeight int64
+
+	MinGasPriceString string
+
+	MinGasPrice = big.NewInt(0)
+
+	// BaseFeeHeight is the height from which the EIP-1559 style base fee is tracked, 0 disables it
+	BaseFeeHeight int64
+
+	// BlockGasLimit is the consensus block gas limit of new chains, and of running chains from BlockGasLimitHeight
+	BlockGasLimit uint64
+
+	BlockGasLimitHeight int64
+
+	// ReceiptBlockHashHeight is the height from which receipts and logs carry the block hash instead of the state root, 0 disables it
+	ReceiptBlockHashHeight int64
 )
 
 func init() {
@@ -46,7 +66,8 @@ func init() {
 	}
 }
 
-func InitConfig() {
+// InitConfig parses the fork heights and versions and the consensus min gas price of the loaded config
+func InitConfig() error {
 	if GitCommit != "" {
 		Version += "-" + GitCommit
 	}
@@ -59,4 +80,13 @@ func InitConfig() {
 		HeightArray[i], _ = strconv.ParseInt(heightStrArray[i], 10, 64)
 		VersionArray[i], _ = strconv.ParseInt(versionStrArray[i], 10, 64)
 	}
+	MinGasPrice = big.NewInt(0)
+	if MinGasPriceString != "" {
+		price, ok := new(big.Int).SetString(MinGasPriceString, 10)
+		if !ok || price.Sign() < 0 {
+			return fmt.Errorf("invalid mingasprice %q, it must be a decimal number of wei", MinGasPriceString)
+		}
+		MinGasPrice = price
+	}
+	return nil
 }
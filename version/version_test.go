@@ -0,0 +1,30 @@
+package version
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitConfigMinGasPrice(t *testing.T) {
+	defer func() {
+		MinGasPriceString = ""
+		MinGasPrice = big.NewInt(0)
+	}()
+	HeightString = "20,30"
+	VersionString = "2,3"
+
+	MinGasPriceString = "1000"
+	assert.NoError(t, InitConfig())
+	assert.Equal(t, big.NewInt(1000), MinGasPrice)
+
+	MinGasPriceString = ""
+	assert.NoError(t, InitConfig())
+	assert.Equal(t, 0, MinGasPrice.Sign())
+
+	for _, price := range []string{"1e9", "0x10", "-1", "1 gwei"} {
+		MinGasPriceString = price
+		assert.Error(t, InitConfig(), price)
+	}
+}